@@ -0,0 +1,74 @@
+package envloader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// Check parses the current environment, runs all validations and hooks,
+// and prints a status line for every variable: ok, default, missing or invalid,
+// followed by any source, constraint and hook errors. Returns whether everything passed.
+//
+// Unlike Parse, Check never exits; wire it to something like a -check-config flag
+// and exit with an appropriate code yourself.
+func (vars VarSet) Check(w io.Writer) bool {
+	res := &ParseResult{}
+	e := vars.parse(&parser{lookup: os.LookupEnv, result: res})
+	if e == nil {
+		e = &Error{}
+	}
+
+	missing := make(map[*Var]bool)
+	for _, vr := range e.MissingVars {
+		missing[vr] = true
+	}
+	invalid := make(map[string]bool)
+	for _, iv := range e.InvalidValues {
+		invalid[iv.EnvKey] = true
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, r := range res.Vars {
+		switch {
+		case invalid[r.Var.EnvKey]:
+			fmt.Fprintf(tw, "invalid\t%s\t%s\n", r.Var.EnvKey, errorWithoutKey(e, r.Var))
+		case missing[r.Var]:
+			if reason := e.MissingReasons[r.Var.EnvKey]; reason != "" {
+				fmt.Fprintf(tw, "missing\t%s\trequired because %s\n", r.Var.EnvKey, reason)
+			} else {
+				fmt.Fprintf(tw, "missing\t%s\t\n", r.Var.EnvKey)
+			}
+		case r.Present:
+			fmt.Fprintf(tw, "ok\t%s\t\n", r.Var.EnvKey)
+		default:
+			fmt.Fprintf(tw, "default\t%s\t\n", r.Var.EnvKey)
+		}
+	}
+	tw.Flush()
+
+	b := e.bullet()
+	for _, err := range e.SourceErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	for _, err := range e.ConstraintErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	for _, key := range e.UnknownKeys {
+		fmt.Fprintf(w, "%sunknown environment variable %s\n", b, key)
+	}
+	for _, err := range e.HookErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	return e.isEmpty()
+}
+
+func errorWithoutKey(e *Error, vr *Var) string {
+	for _, iv := range e.InvalidValues {
+		if iv.EnvKey == vr.EnvKey {
+			return iv.causeString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package envloader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckNamed(t *testing.T) {
+	var host string
+	var vars VarSet
+	vars.Name("database")
+	vars.Var("ZZ_CHECK_HOST", Optional, StringVar(&host), "")
+	vars.Constrain(func() error { return errors.New("host is not reachable") })
+
+	var buf strings.Builder
+	if vars.Check(&buf) {
+		t.Fatal("Check passed, wanted a constraint error")
+	}
+	if out := buf.String(); !strings.Contains(out, "[database] ** host is not reachable\n") {
+		t.Errorf("output:\n%s", out)
+	}
+}
@@ -0,0 +1,43 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Cloner is implemented by values that can make a copy of themselves backed
+// by newly allocated storage, initialized with the current value.
+// All value types defined by this package implement Cloner.
+type Cloner interface {
+	Clone() flag.Value
+}
+
+// Clone returns a deep copy of the set in which every Var and every value has
+// its own storage, so that the copy can be parsed independently of the original,
+// e.g. once per tenant. Access the parsed values via the Value fields
+// of the copied Vars.
+//
+// Values that do not implement Cloner are shared between the original
+// and the copy. Required funcs and other callbacks are shared as well,
+// so the ones referring to bound Go variables (like WhenTrue) keep referring
+// to those of the original set. Required funcs referring to Vars (like WhenSet
+// and WhenEquals) and OneOf cannot be redirected to the copied Vars, so Clone
+// panics when a Var of the set is referred to by them; declare such sets
+// in a function and call it once per copy instead.
+func (vars VarSet) Clone() VarSet {
+	for _, vr := range vars {
+		if vr.referenced {
+			panic(fmt.Sprintf("envloader: Clone: %s is referred to by a Required func or OneOf, which would keep referring to the original", vr.EnvKey))
+		}
+	}
+	result := make(VarSet, len(vars))
+	for i, vr := range vars {
+		c := *vr
+		if cl, ok := vr.Value.(Cloner); ok {
+			c.Value = cl.Clone()
+			c.reset = nil // the declared value is unknown; see resetToDefault
+		}
+		result[i] = &c
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package envloader
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Run with -race: these exercise the concurrent usage that VarSet documents as safe.
+
+func TestCloneParseConcurrently(t *testing.T) {
+	var vars VarSet
+	vars.Var("PORT", Required, NewInt(0), "")
+	vars.Var("HOSTS", Optional, StringSliceVar(new([]string)), "")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := vars.Clone()
+			port := strconv.Itoa(8000 + i)
+			if err := c.TryParseFrom(func(key string) string {
+				if key == "PORT" {
+					return port
+				}
+				return "a,b"
+			}); err != nil {
+				t.Error(err)
+				return
+			}
+			if got := c[0].Value.String(); got != port || !c[0].IsSpecified {
+				t.Errorf("PORT = %q, specified=%v, wanted %s", got, c[0].IsSpecified, port)
+			}
+		}()
+	}
+	wg.Wait()
+	if vars[0].IsSpecified || vars[0].Value.String() != "0" {
+		t.Errorf("the original set changed: %v", vars[0].Value)
+	}
+}
+
+func TestReloadViaPointerSwap(t *testing.T) {
+	type config struct {
+		Port int
+	}
+	load := func(port string) *config {
+		cfg := new(config)
+		var vars VarSet
+		vars.Var("PORT", Required, IntVar(&cfg.Port), "")
+		if err := vars.TryParseFrom(func(string) string { return port }); err != nil {
+			t.Error(err)
+			return nil
+		}
+		return cfg
+	}
+	var current atomic.Pointer[config]
+	current.Store(load("1"))
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if cfg := current.Load(); cfg.Port < 1 {
+					t.Errorf("read port %d", cfg.Port)
+					return
+				}
+			}
+		}()
+	}
+	for i := 2; i <= 50; i++ {
+		if cfg := load(strconv.Itoa(i)); cfg != nil {
+			current.Store(cfg)
+		}
+	}
+	close(done)
+	wg.Wait()
+	if got := current.Load().Port; got != 50 {
+		t.Errorf("port = %d, wanted 50", got)
+	}
+}
+
+func TestCloneParsesIndependently(t *testing.T) {
+	var vars VarSet
+	vars.Var("HOST", Required, NewString(""), "")
+	vars.Var("PORT", Optional, NewInt(80), "")
+	c := vars.Clone()
+
+	tests := []struct {
+		name    string
+		vars    VarSet
+		env     map[string]string
+		missing int
+		host    string
+		port    string
+	}{
+		{"original", vars, map[string]string{"PORT": "8080"}, 1, "", "8080"},
+		{"clone", c, map[string]string{"HOST": "example.com"}, 0, "example.com", "80"},
+	}
+	for _, tt := range tests {
+		var missing []*Var
+		if e := tt.vars.TryParseMap(tt.env); e != nil {
+			missing = e.MissingVars
+		}
+		if len(missing) != tt.missing {
+			t.Errorf("%s: missing %d variables, wanted %d", tt.name, len(missing), tt.missing)
+		}
+		if host, port := tt.vars[0].Value.String(), tt.vars[1].Value.String(); host != tt.host || port != tt.port {
+			t.Errorf("%s: HOST=%q PORT=%q, wanted %q and %q", tt.name, host, port, tt.host, tt.port)
+		}
+	}
+}
+
+func TestCloneRejectsVarReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		declare func(vars *VarSet)
+	}{
+		{"WhenSet", func(vars *VarSet) {
+			db := vars.Var("DB_URL", Optional, NewString(""), "")
+			vars.Var("DB_PASSWORD", WhenSet(db), NewString(""), "")
+		}},
+		{"UnlessSet", func(vars *VarSet) {
+			db := vars.Var("DB_URL", Optional, NewString(""), "")
+			vars.Var("DB_HOST", UnlessSet(db), NewString(""), "")
+		}},
+		{"WhenEquals", func(vars *VarSet) {
+			mode := vars.Var("MODE", Optional, NewString(""), "")
+			vars.Var("CERT", WhenEquals(mode, "tls"), NewString(""), "")
+		}},
+		{"WhenEnvIsOneOf", func(vars *VarSet) {
+			env := vars.Var("ENV", Optional, NewString(""), "")
+			vars.Var("SENTRY_DSN", WhenEnvIsOneOf(env, "production"), NewString(""), "")
+		}},
+		{"WhenNotEmpty", func(vars *VarSet) {
+			user := vars.Var("USER", Optional, NewString(""), "")
+			vars.Var("PASSWORD", WhenNotEmpty(user), NewString(""), "")
+		}},
+		{"OneOf", func(vars *VarSet) {
+			var file VarSet
+			file.Var("PATH", Required, NewString(""), "")
+			mode := vars.Var("STORAGE", Required, NewString(""), "")
+			vars.OneOf(mode, map[string]VarSet{"file": file})
+		}},
+	}
+	for _, tt := range tests {
+		var vars VarSet
+		tt.declare(&vars)
+		var msg string
+		func() {
+			defer func() { msg, _ = recover().(string) }()
+			vars.Clone()
+		}()
+		if !strings.HasPrefix(msg, "envloader: Clone: ") {
+			t.Errorf("%s: Clone panicked with %q, wanted it to reject the set", tt.name, msg)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package envloader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func (vars *VarSet) addCheck(check func(vars VarSet, e *Error)) {
+	vars.addMarker(&Var{check: check})
+}
+
+// Constrain registers a cross-variable validation function that runs after
+// all variables have been parsed, as part of TryParse and friends (and thus Parse).
+// A returned error is reported via Error.ConstraintErrors.
+func (vars *VarSet) Constrain(fn func() error) {
+	vars.addCheck(func(vars VarSet, e *Error) {
+		if err := fn(); err != nil {
+			e.ConstraintErrors = append(e.ConstraintErrors, err)
+		}
+	})
+}
+
+// RequireTogether declares that the given variables must be specified either
+// all together or not at all. When any of them is specified, the rest become
+// required, regardless of their own Required funcs.
+//
+// The keys can refer to variables declared before or after this call.
+func (vars *VarSet) RequireTogether(envKeys ...string) {
+	vars.addCheck(func(vars VarSet, e *Error) {
+		group := make([]*Var, len(envKeys))
+		var first *Var
+		for i, key := range envKeys {
+			group[i] = vars.mustLookup(key)
+			if first == nil && group[i].IsSpecified {
+				first = group[i]
+			}
+		}
+		if first == nil {
+			return
+		}
+		for _, vr := range group {
+			if !vr.IsSpecified {
+				e.addMissing(vr, fmt.Sprintf("%s is set", first.EnvKey))
+			}
+		}
+	})
+}
+
+// RequireAtLeastOne declares that at least one of the given variables
+// must be specified, reporting a constraint error otherwise.
+func (vars *VarSet) RequireAtLeastOne(envKeys ...string) {
+	vars.addCheck(func(vars VarSet, e *Error) {
+		for _, key := range envKeys {
+			if vars.mustLookup(key).IsSpecified {
+				return
+			}
+		}
+		e.ConstraintErrors = append(e.ConstraintErrors, fmt.Errorf("at least one of %s must be set", strings.Join(envKeys, ", ")))
+	})
+}
+
+// RequireAtMost declares that at most n of the given variables may be
+// specified, e.g. at most one of several alternative overrides, reporting
+// a constraint error that lists the specified ones otherwise.
+func (vars *VarSet) RequireAtMost(n int, envKeys ...string) {
+	vars.addCheck(func(vars VarSet, e *Error) {
+		var set []string
+		for _, key := range envKeys {
+			if vars.mustLookup(key).IsSpecified {
+				set = append(set, key)
+			}
+		}
+		if len(set) > n {
+			e.ConstraintErrors = append(e.ConstraintErrors, fmt.Errorf("at most %d of %s may be set, got %s", n, strings.Join(envKeys, ", "), strings.Join(set, ", ")))
+		}
+	})
+}
+
+// OneOf declares alternative groups of variables selected by the value of
+// selector, e.g. a BACKEND_TYPE of s3 or gcs choosing between S3_* and GCS_*
+// variables. The variables, sections and constraints of cases[v] only apply
+// when selector's value is v: variables of the other cases are ignored entirely,
+// neither parsed nor required, even if present in the environment. A specified
+// selector value without a case is reported as a constraint error.
+//
+// The selector must be declared before this call. The cases are added
+// to the set in the order of their keys; their Vars are shared, not copied.
+// Like Merge, OneOf panics if a key is declared more than once, whether by two
+// cases or by a case and the set, and it panics if a case configures set-wide
+// settings like TrimSpace, which cannot depend on the selector.
+func (vars *VarSet) OneOf(selector *Var, cases map[string]VarSet) {
+	keys := make([]string, 0, len(cases))
+	for key := range cases {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[string]bool)
+	for _, vr := range *vars {
+		if !vr.isMarker() {
+			seen[vr.EnvKey] = true
+		}
+	}
+	for _, key := range keys {
+		for _, vr := range cases[key] {
+			if vr.apply != nil || vr.frozen {
+				panic(fmt.Sprintf("envloader: OneOf: case %s must not change set-wide settings", key))
+			}
+			if vr.isMarker() {
+				continue
+			}
+			if seen[vr.EnvKey] {
+				panic(fmt.Sprintf("envloader: OneOf: duplicate variable %s", vr.EnvKey))
+			}
+			seen[vr.EnvKey] = true
+		}
+	}
+
+	selector.referenced = true
+	vars.addCheck(func(vars VarSet, e *Error) {
+		if _, ok := cases[selector.Value.String()]; selector.IsSpecified && !ok {
+			e.ConstraintErrors = append(e.ConstraintErrors, fmt.Errorf("%s must be one of %s", selector.EnvKey, strings.Join(keys, ", ")))
+		}
+	})
+	for _, key := range keys {
+		key := key
+		selected := func() bool {
+			return selector.Value.String() == key
+		}
+		for _, vr := range cases[key] {
+			if check := vr.check; check != nil {
+				vars.addCheck(func(vars VarSet, e *Error) {
+					if selected() {
+						check(vars, e)
+					}
+				})
+				continue
+			}
+			vars.checkNotFrozen()
+			vr.gate = selected
+			*vars = append(*vars, vr)
+		}
+	}
+}
+
+// isActive returns false for the variables of OneOf cases that are not selected.
+func (vr *Var) isActive() bool {
+	return vr.gate == nil || vr.gate()
+}
@@ -0,0 +1,162 @@
+package envloader
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRequireTogetherUnknownKey(t *testing.T) {
+	var a, b string
+	var vars VarSet
+	vars.Var("A", Optional, StringVar(&a), "")
+	vars.Var("B", Optional, StringVar(&b), "")
+	vars.RequireTogether("A", "BTYPO")
+
+	for _, env := range []map[string]string{{"A": "1"}, {}} {
+		err := vars.TryParseMap(env)
+		if err == nil || !strings.Contains(err.Error(), "unknown variable BTYPO") {
+			t.Errorf("env %v: got %v, wanted an unknown variable error", env, err)
+		}
+	}
+}
+
+func TestRequireTogether(t *testing.T) {
+	parse := func(env map[string]string) *Error {
+		var a, b string
+		var vars VarSet
+		vars.Var("A", Optional, StringVar(&a), "")
+		vars.Var("B", Optional, StringVar(&b), "")
+		vars.RequireTogether("A", "B")
+		return vars.TryParseMap(env)
+	}
+
+	if err := parse(map[string]string{}); err != nil {
+		t.Errorf("none set: %v", err)
+	}
+	if err := parse(map[string]string{"A": "1", "B": "2"}); err != nil {
+		t.Errorf("both set: %v", err)
+	}
+	err := parse(map[string]string{"A": "1"})
+	if err == nil || len(err.MissingVars) != 1 || err.MissingReasons["B"] != "A is set" {
+		t.Errorf("A set: got %v", err)
+	}
+}
+
+func TestParseExitsOnConstraint(t *testing.T) {
+	if os.Getenv("ENVLOADER_TEST_PARSE_EXIT") == "1" {
+		var vars VarSet
+		vars.Var("A", Optional, NewString(""), "")
+		vars.Var("B", Optional, NewString(""), "")
+		vars.RequireAtLeastOne("A", "B")
+		vars.Parse()
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestParseExitsOnConstraint$")
+	cmd.Env = []string{"ENVLOADER_TEST_PARSE_EXIT=1"}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() != 2 {
+		t.Fatalf("got %v, wanted exit code 2", err)
+	}
+	if want := "at least one of A, B must be set"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("printed %q, wanted %q", stderr.String(), want)
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	newSet := func() (VarSet, *string, *string) {
+		var bucket, project string
+		var vars VarSet
+		backend := vars.Var("BACKEND", Required, NewString(""), "")
+		var s3, gcs VarSet
+		s3.Var("S3_BUCKET", Required, StringVar(&bucket), "")
+		gcs.Var("GCS_PROJECT", Required, StringVar(&project), "")
+		vars.OneOf(backend, map[string]VarSet{"s3": s3, "gcs": gcs})
+		return vars, &bucket, &project
+	}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		missing string
+		bucket  string
+		project string
+		errs    string
+	}{
+		{"selected", map[string]string{"BACKEND": "s3", "S3_BUCKET": "b", "GCS_PROJECT": "p"}, "", "b", "", ""},
+		{"selected missing", map[string]string{"BACKEND": "gcs", "S3_BUCKET": "b"}, "GCS_PROJECT", "", "", ""},
+		{"unknown selector", map[string]string{"BACKEND": "azure", "S3_BUCKET": "b"}, "", "", "", "BACKEND must be one of gcs, s3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, bucket, project := newSet()
+			e := vars.TryParseFrom(func(key string) string { return tt.env[key] })
+			var missing, errs []string
+			if e != nil {
+				for _, vr := range e.MissingVars {
+					missing = append(missing, vr.EnvKey)
+				}
+				for _, err := range e.ConstraintErrors {
+					errs = append(errs, err.Error())
+				}
+			}
+			if got := strings.Join(missing, ","); got != tt.missing {
+				t.Errorf("missing %q, wanted %q", got, tt.missing)
+			}
+			if got := strings.Join(errs, ";"); got != tt.errs {
+				t.Errorf("constraint errors %q, wanted %q", got, tt.errs)
+			}
+			if *bucket != tt.bucket || *project != tt.project {
+				t.Errorf("bucket=%q project=%q, wanted %q and %q", *bucket, *project, tt.bucket, tt.project)
+			}
+		})
+	}
+}
+
+func TestOneOfRejectsMisuse(t *testing.T) {
+	dup := func() (vars VarSet, cases map[string]VarSet) {
+		var a, b VarSet
+		a.Var("REGION", Optional, NewString(""), "")
+		b.Var("REGION", Optional, NewString(""), "")
+		return nil, map[string]VarSet{"a": a, "b": b}
+	}
+	shadow := func() (vars VarSet, cases map[string]VarSet) {
+		vars.Var("REGION", Optional, NewString(""), "")
+		var a VarSet
+		a.Var("REGION", Optional, NewString(""), "")
+		return vars, map[string]VarSet{"a": a}
+	}
+	settings := func() (vars VarSet, cases map[string]VarSet) {
+		var a VarSet
+		a.TrimSpace()
+		a.Var("A_KEY", Optional, NewString(""), "")
+		return nil, map[string]VarSet{"a": a}
+	}
+	tests := []struct {
+		name  string
+		setup func() (VarSet, map[string]VarSet)
+		want  string
+	}{
+		{"two cases", dup, "duplicate variable REGION"},
+		{"case and set", shadow, "duplicate variable REGION"},
+		{"settings", settings, "case a must not change set-wide settings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, cases := tt.setup()
+			selector := vars.Var("MODE", Optional, NewString(""), "")
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, tt.want) {
+					t.Errorf("recovered %q, wanted %q", msg, tt.want)
+				}
+			}()
+			vars.OneOf(selector, cases)
+		})
+	}
+}
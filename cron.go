@@ -0,0 +1,203 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CronVar returns a value that validates cron expressions like 0 2 * * *,
+// storing the expression as given. Standard 5-field expressions (minute, hour,
+// day of month, month, day of week) are accepted, as well as 6-field ones
+// starting with seconds, and the @yearly, @monthly, @weekly, @daily and @hourly
+// shortcuts. Fields support *, lists, ranges, steps like */15, and the names
+// of months and weekdays. Use Schedule for the expanded fields.
+//
+// This only validates expressions; running the jobs is up to the scheduler.
+func CronVar(v *string) *Cron {
+	return &Cron{v: v}
+}
+
+type Cron struct {
+	v     *string
+	expr  string // the expression that sched was parsed from
+	sched CronSchedule
+}
+
+// CronSchedule lists the values matched by each field of a cron expression,
+// in ascending order. Second is [0] for 5-field expressions; Weekday uses
+// 0 for Sunday.
+type CronSchedule struct {
+	Second, Minute, Hour, Day, Month, Weekday []int
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+	names    []string
+}{
+	{"second", 0, 59, nil},
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{"day of week", 0, 7, []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// Schedule returns the expanded fields of the expression. An expression that
+// was not Set, like the default held by the bound variable, is parsed on each
+// call; an invalid one yields an empty schedule.
+func (v *Cron) Schedule() CronSchedule {
+	if v.v == nil || *v.v == v.expr {
+		return v.sched
+	}
+	sched, _ := parseCron(*v.v)
+	return sched
+}
+
+func (v *Cron) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return *v.v
+}
+
+func (v *Cron) Get() interface{} {
+	return v.Schedule()
+}
+
+func (v *Cron) Set(raw string) error {
+	sched, err := parseCron(raw)
+	if err != nil {
+		return err
+	}
+	*v.v = raw
+	v.expr, v.sched = raw, sched
+	return nil
+}
+
+func (v *Cron) Clone() flag.Value {
+	c := *v.v
+	return &Cron{&c, v.expr, v.sched}
+}
+
+func (v *Cron) TypeName() string {
+	return "cron"
+}
+
+func (v *Cron) saveState() func() {
+	s, expr, sched := *v.v, v.expr, v.sched
+	return func() {
+		*v.v, v.expr, v.sched = s, expr, sched
+	}
+}
+
+func parseCron(raw string) (CronSchedule, error) {
+	expr := strings.TrimSpace(raw)
+	if strings.HasPrefix(expr, "@") {
+		m, ok := cronMacros[strings.ToLower(expr)]
+		if !ok {
+			return CronSchedule{}, fmt.Errorf("unknown cron shortcut %q", expr)
+		}
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return CronSchedule{}, fmt.Errorf("expected a cron expression with 5 fields (minute hour day month weekday), or 6 starting with seconds, got %d fields", len(fields))
+	}
+	var sets [6][]int
+	for i, f := range cronFields {
+		values, err := parseCronField(fields[i], f.min, f.max, f.names)
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("invalid %s field %q: %w", f.name, fields[i], err)
+		}
+		sets[i] = values
+	}
+	// 7 is an alias of Sunday
+	if wd := sets[5]; wd[len(wd)-1] == 7 {
+		wd = wd[:len(wd)-1]
+		if len(wd) == 0 || wd[0] != 0 {
+			wd = append([]int{0}, wd...)
+		}
+		sets[5] = wd
+	}
+	return CronSchedule{sets[0], sets[1], sets[2], sets[3], sets[4], sets[5]}, nil
+}
+
+func parseCronField(field string, min, max int, names []string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		lo, hi := min, max
+		switch {
+		case rng == "*" || rng == "?":
+		case rng == "":
+			return nil, fmt.Errorf("empty list element")
+		default:
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = cronNumber(from, min, max, names); err != nil {
+				return nil, err
+			}
+			if isRange {
+				if hi, err = cronNumber(to, min, max, names); err != nil {
+					return nil, err
+				}
+				if hi < lo {
+					return nil, fmt.Errorf("range %s is backwards", rng)
+				}
+			} else if !hasStep {
+				hi = lo
+			}
+		}
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+		for n := lo; n <= hi; n += step {
+			seen[n] = true
+		}
+	}
+	values := make([]int, 0, len(seen))
+	for n := range seen {
+		values = append(values, n)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+func cronNumber(s string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return i + min, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", n, min, max)
+	}
+	return n, nil
+}
@@ -0,0 +1,77 @@
+package envloader
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCron(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want CronSchedule
+		err  string
+	}{
+		{"0 2 * * *", CronSchedule{Second: []int{0}, Minute: []int{0}, Hour: []int{2}, Day: seq(1, 31), Month: seq(1, 12), Weekday: seq(0, 6)}, ""},
+		{"30 */15 9-17 * * mon-fri", CronSchedule{Second: []int{30}, Minute: []int{0, 15, 30, 45}, Hour: seq(9, 17), Day: seq(1, 31), Month: seq(1, 12), Weekday: seq(1, 5)}, ""},
+		{"5/15 0 1,15 jan,Jul 7", CronSchedule{Second: []int{0}, Minute: []int{5, 20, 35, 50}, Hour: []int{0}, Day: []int{1, 15}, Month: []int{1, 7}, Weekday: []int{0}}, ""},
+		{"0 0 * * 0,7", CronSchedule{Second: []int{0}, Minute: []int{0}, Hour: []int{0}, Day: seq(1, 31), Month: seq(1, 12), Weekday: []int{0}}, ""},
+		{"0 0 * * 5-7", CronSchedule{Second: []int{0}, Minute: []int{0}, Hour: []int{0}, Day: seq(1, 31), Month: seq(1, 12), Weekday: []int{0, 5, 6}}, ""},
+		{"@Weekly", CronSchedule{Second: []int{0}, Minute: []int{0}, Hour: []int{0}, Day: seq(1, 31), Month: seq(1, 12), Weekday: []int{0}}, ""},
+		{"@often", CronSchedule{}, `unknown cron shortcut "@often"`},
+		{"* * * *", CronSchedule{}, "got 4 fields"},
+		{"*/0 * * * *", CronSchedule{}, `invalid minute field "*/0": invalid step "0"`},
+		{"60 * * * *", CronSchedule{}, `invalid minute field "60": 60 is out of range 0-59`},
+		{"* 24 * * *", CronSchedule{}, "24 is out of range 0-23"},
+		{"* * 0 * *", CronSchedule{}, "invalid day of month field"},
+		{"* * * 13 *", CronSchedule{}, "13 is out of range 1-12"},
+		{"* * * * 8", CronSchedule{}, "8 is out of range 0-7"},
+		{"* * * * foo", CronSchedule{}, `"foo" is not a number`},
+		{"10-5 * * * *", CronSchedule{}, "range 10-5 is backwards"},
+		{"1,,2 * * * *", CronSchedule{}, "empty list element"},
+	}
+	for _, tt := range tests {
+		var s string
+		v := CronVar(&s)
+		err := v.Set(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.raw, err)
+			continue
+		}
+		if got := v.Schedule(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, wanted %v", tt.raw, got, tt.want)
+		}
+		if s != tt.raw {
+			t.Errorf("%q: stored %q", tt.raw, s)
+		}
+	}
+}
+
+func TestCronDefault(t *testing.T) {
+	s := "@hourly"
+	v := CronVar(&s)
+	sched, ok := v.Get().(CronSchedule)
+	if !ok || !reflect.DeepEqual(sched.Minute, []int{0}) || len(sched.Hour) != 24 {
+		t.Errorf("Get() of the default = %v", v.Get())
+	}
+	if err := v.Set("0 3 * * *"); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Schedule().Hour; !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("hours after Set = %v", got)
+	}
+}
+
+func seq(from, to int) []int {
+	var result []int
+	for n := from; n <= to; n++ {
+		result = append(result, n)
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// DNSLabelVar returns a value that accepts DNS labels per RFC 1123, as required
+// for many Kubernetes resource names: 1 to 63 lowercase letters, digits and
+// hyphens, starting and ending with a letter or digit.
+func DNSLabelVar(v *string) *DNSLabel {
+	return (*DNSLabel)(v)
+}
+
+type DNSLabel string
+
+func (v DNSLabel) String() string {
+	return string(v)
+}
+
+func (v DNSLabel) Get() interface{} {
+	return string(v)
+}
+
+func (v *DNSLabel) Set(raw string) error {
+	if err := checkDNSLabel(raw); err != nil {
+		return err
+	}
+	*v = DNSLabel(raw)
+	return nil
+}
+
+func (v *DNSLabel) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *DNSLabel) TypeName() string {
+	return "dnslabel"
+}
+
+// DNSSubdomainVar returns a value that accepts DNS subdomains per RFC 1123:
+// up to 253 characters of dot-separated DNS labels, like api.example.com.
+func DNSSubdomainVar(v *string) *DNSSubdomain {
+	return (*DNSSubdomain)(v)
+}
+
+type DNSSubdomain string
+
+func (v DNSSubdomain) String() string {
+	return string(v)
+}
+
+func (v DNSSubdomain) Get() interface{} {
+	return string(v)
+}
+
+func (v *DNSSubdomain) Set(raw string) error {
+	if len(raw) > 253 {
+		return fmt.Errorf("must be at most 253 characters, got %d", len(raw))
+	}
+	for _, label := range strings.Split(raw, ".") {
+		if err := checkDNSLabel(label); err != nil {
+			if label == "" {
+				return fmt.Errorf("must not contain empty labels")
+			}
+			return fmt.Errorf("label %q: %w", label, err)
+		}
+	}
+	*v = DNSSubdomain(raw)
+	return nil
+}
+
+func (v *DNSSubdomain) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *DNSSubdomain) TypeName() string {
+	return "dnssubdomain"
+}
+
+func checkDNSLabel(s string) error {
+	switch {
+	case s == "":
+		return fmt.Errorf("must not be empty")
+	case len(s) > 63:
+		return fmt.Errorf("must be at most 63 characters, got %d", len(s))
+	case s[0] == '-':
+		return fmt.Errorf("must not start with a hyphen")
+	case s[len(s)-1] == '-':
+		return fmt.Errorf("must not end with a hyphen")
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+		case c >= 'A' && c <= 'Z':
+			return fmt.Errorf("must be lowercase, found %q", c)
+		default:
+			return fmt.Errorf("may only contain lowercase letters, digits and hyphens, found %q", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDNSLabel(t *testing.T) {
+	tests := []struct {
+		raw string
+		err string
+	}{
+		{"api", ""},
+		{"api-1", ""},
+		{"1a", ""},
+		{strings.Repeat("a", 63), ""},
+		{"", "must not be empty"},
+		{strings.Repeat("a", 64), "must be at most 63 characters, got 64"},
+		{"-api", "must not start with a hyphen"},
+		{"api-", "must not end with a hyphen"},
+		{"Api", "must be lowercase"},
+		{"api.example", "may only contain lowercase letters, digits and hyphens"},
+		{"api_1", "may only contain lowercase letters, digits and hyphens"},
+	}
+	for _, tt := range tests {
+		var s string
+		err := DNSLabelVar(&s).Set(tt.raw)
+		if tt.err == "" {
+			if err != nil || s != tt.raw {
+				t.Errorf("%q: got %q, %v", tt.raw, s, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+		}
+	}
+}
+
+func TestDNSSubdomain(t *testing.T) {
+	long := strings.Repeat(strings.Repeat("a", 63)+".", 4)
+	tests := []struct {
+		raw string
+		err string
+	}{
+		{"api.example.com", ""},
+		{"localhost", ""},
+		{long[:253], ""},
+		{long[:254], "must be at most 253 characters, got 254"},
+		{"", "must not contain empty labels"},
+		{"api..example", "must not contain empty labels"},
+		{"api.example.", "must not contain empty labels"},
+		{"api.-x.com", `label "-x": must not start with a hyphen`},
+		{"API.example.com", `label "API": must be lowercase`},
+	}
+	for _, tt := range tests {
+		var s string
+		err := DNSSubdomainVar(&s).Set(tt.raw)
+		if tt.err == "" {
+			if err != nil || s != tt.raw {
+				t.Errorf("%q: got %q, %v", tt.raw, s, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package envloader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadDotenvFile reads KEY=VALUE assignments from a dotenv-style file,
+// see ParseDotenv for the format.
+func ReadDotenvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	if err := parseDotenv(string(data), path, result, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ParseDotenv reads KEY=VALUE assignments in the dotenv format:
+//
+//	# comments and blank lines are ignored
+//	export DB_HOST=localhost   # an optional export prefix, trailing comments
+//	DB_NAME='literal value'
+//	MOTD="double-quoted values support \n, \t, \", \\ and \$ escapes,
+//	and can span several lines"
+//	include common.env         # the assignments of another file
+//
+// Unquoted values are trimmed. When a key occurs several times, the last
+// assignment wins, so the lines after an include override the included file.
+// Relative include paths are resolved against the directory of name,
+// recursively; a file including itself, directly or not, is an error.
+// Errors mention the given name and the line number.
+func ParseDotenv(r io.Reader, name string) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	if err := parseDotenv(string(data), name, result, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseDotenv adds the assignments of data to result. The including stack
+// lists the absolute paths of the files being parsed, for cycle detection.
+func parseDotenv(data, name string, result map[string]string, including []string) error {
+	if abs, err := filepath.Abs(name); err == nil {
+		including = append(including, abs)
+	}
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineno := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		if path, ok := strings.CutPrefix(line, "include "); ok && !strings.HasPrefix(strings.TrimSpace(path), "=") {
+			if err := includeDotenv(strings.TrimSpace(path), name, lineno, result, including); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key, rest, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", name, lineno)
+		}
+		if !isEnvKey(key) {
+			return fmt.Errorf("%s:%d: invalid variable name %q", name, lineno, key)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value string
+		switch {
+		case strings.HasPrefix(rest, "'"):
+			end := strings.IndexByte(rest[1:], '\'')
+			if end < 0 {
+				return fmt.Errorf("%s:%d: unterminated single-quoted value of %s", name, lineno, key)
+			}
+			value = rest[1 : 1+end]
+			if err := checkTrailing(rest[2+end:]); err != nil {
+				return fmt.Errorf("%s:%d: %v", name, lineno, err)
+			}
+		case strings.HasPrefix(rest, `"`):
+			var buf strings.Builder
+			s := rest[1:]
+			for {
+				end, err := unquoteDouble(s, &buf)
+				if err != nil {
+					return fmt.Errorf("%s:%d: %v", name, lineno, err)
+				}
+				if end >= 0 {
+					if err := checkTrailing(s[end+1:]); err != nil {
+						return fmt.Errorf("%s:%d: %v", name, lineno, err)
+					}
+					break
+				}
+				i++
+				if i >= len(lines) {
+					return fmt.Errorf("%s:%d: unterminated double-quoted value of %s", name, lineno, key)
+				}
+				buf.WriteByte('\n')
+				s = lines[i]
+			}
+			value = buf.String()
+		default:
+			if idx := strings.Index(rest, " #"); idx >= 0 {
+				rest = rest[:idx]
+			} else if idx := strings.Index(rest, "\t#"); idx >= 0 {
+				rest = rest[:idx]
+			}
+			value = strings.TrimSpace(rest)
+		}
+		result[key] = value
+	}
+	return nil
+}
+
+func includeDotenv(path, name string, lineno int, result map[string]string, including []string) error {
+	if len(path) >= 2 && (path[0] == '"' || path[0] == '\'') && path[len(path)-1] == path[0] {
+		path = path[1 : len(path)-1]
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(name), path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		for i, p := range including {
+			if p == abs {
+				return fmt.Errorf("%s:%d: include cycle: %s", name, lineno, strings.Join(append(including[i:], abs), " -> "))
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%s:%d: cannot include: %v", name, lineno, err)
+	}
+	return parseDotenv(string(data), path, result, including)
+}
+
+// unquoteDouble appends the contents of a double-quoted string to buf,
+// returning the index of the closing quote in s, or -1 if s ends first.
+func unquoteDouble(s string, buf *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return i, nil
+		case '\\':
+			i++
+			if i >= len(s) {
+				return -1, fmt.Errorf("backslash at end of line")
+			}
+			switch s[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '"', '\\', '$':
+				buf.WriteByte(s[i])
+			default:
+				return -1, fmt.Errorf("invalid escape sequence \\%c", s[i])
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return -1, nil
+}
+
+func checkTrailing(s string) error {
+	s = strings.TrimSpace(s)
+	if s != "" && !strings.HasPrefix(s, "#") {
+		return fmt.Errorf("unexpected text after closing quote: %q", s)
+	}
+	return nil
+}
+
+func isEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		case c == '.' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseWithConfigFile parses the variables from the process environment
+// layered over a dotenv-style config file of the given app, see ParseDotenv
+// for the format. The first existing file among these is used:
+//
+//	$XDG_CONFIG_HOME/<appName>/config.env
+//	<os.UserConfigDir>/<appName>/config.env
+//	~/.config/<appName>/config.env
+//	/etc/<appName>/config.env
+//
+// Variables present in the environment override the ones from the file.
+// Not having any config file is fine; a file that cannot be read or parsed
+// is reported via Error.SourceErrors, and nothing is parsed in that case.
+func (vars VarSet) ParseWithConfigFile(appName string) *Error {
+	var fileVars map[string]string
+	for _, path := range configFileCandidates(appName) {
+		m, err := ReadDotenvFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			e := vars.settings().newError()
+			e.SourceErrors = append(e.SourceErrors, err)
+			return e
+		}
+		fileVars = m
+		break
+	}
+	return vars.parse(&parser{lookup: func(key string) (string, bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			return v, true
+		}
+		v, ok := fileVars[key]
+		return v, ok
+	}})
+}
+
+func configFileCandidates(appName string) []string {
+	var dirs []string
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+	dirs = append(dirs, "/etc")
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		path := filepath.Join(dir, appName, "config.env")
+		if !seen[path] {
+			seen[path] = true
+			result = append(result, path)
+		}
+	}
+	return result
+}
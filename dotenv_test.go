@@ -0,0 +1,165 @@
+package envloader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseWithConfigFileNamedError(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "app", "config.env"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var vars VarSet
+	vars.Name("app")
+	e := vars.ParseWithConfigFile("app")
+	if e == nil || len(e.SourceErrors) != 1 {
+		t.Fatalf("got %v, wanted a source error", e)
+	}
+	var buf strings.Builder
+	PrintError(e, &buf)
+	if !strings.HasPrefix(buf.String(), "[app] ** ") {
+		t.Errorf("printed %q, wanted the set name", buf.String())
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+		err   string
+	}{
+		{"unquoted", "A=hello world  ", map[string]string{"A": "hello world"}, ""},
+		{"export and comments", "# comment\n\nexport A=1 # trailing\nB=2\t# tab", map[string]string{"A": "1", "B": "2"}, ""},
+		{"hash inside unquoted", "URL=http://x/#anchor", map[string]string{"URL": "http://x/#anchor"}, ""},
+		{"empty", "A=\nB=''\nC=\"\"", map[string]string{"A": "", "B": "", "C": ""}, ""},
+		{"single quotes are literal", `A='a \n $B "x" # y'`, map[string]string{"A": `a \n $B "x" # y`}, ""},
+		{"double quote escapes", `A="tab\there\nnl \"q\" \\ \$HOME # not a comment"`, map[string]string{"A": "tab\there\nnl \"q\" \\ $HOME # not a comment"}, ""},
+		{"multiline", "A=\"line 1\nline 2\"\nB=3", map[string]string{"A": "line 1\nline 2", "B": "3"}, ""},
+		{"comment after quotes", `A='x' # c` + "\n" + `B="y"	# c`, map[string]string{"A": "x", "B": "y"}, ""},
+		{"crlf", "A=1\r\nB='2'\r\n", map[string]string{"A": "1", "B": "2"}, ""},
+		{"last wins", "A=1\nA=2", map[string]string{"A": "2"}, ""},
+		{"unterminated single", "A=1\nB='x", nil, "test.env:2: unterminated single-quoted value of B"},
+		{"unterminated double", "A=1\n\nB=\"x\ny", nil, "test.env:3: unterminated double-quoted value of B"},
+		{"text after quote", "A='x' y", nil, `test.env:1: unexpected text after closing quote: "y"`},
+		{"bad escape", `A="\q"`, nil, `test.env:1: invalid escape sequence \q`},
+		{"no equals", "A=1\nJUST_A_KEY", nil, "test.env:2: expected KEY=VALUE"},
+		{"bad key", "1A=x", nil, `test.env:1: invalid variable name "1A"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(strings.NewReader(tt.input), "test.env")
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v, wanted %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFileCandidates(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("os.UserConfigDir differs on", runtime.GOOS)
+	}
+	t.Setenv("HOME", "/home/u")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	want := []string{"/xdg/app/config.env", "/home/u/.config/app/config.env", "/etc/app/config.env"}
+	if got := configFileCandidates("app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+	t.Setenv("XDG_CONFIG_HOME", "")
+	want = []string{"/home/u/.config/app/config.env", "/etc/app/config.env"}
+	if got := configFileCandidates("app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("without XDG_CONFIG_HOME: got %q, wanted %q", got, want)
+	}
+}
+
+func TestParseWithConfigFile(t *testing.T) {
+	xdg, home := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("HOME", home)
+	writeFile(t, filepath.Join(home, ".config", "app", "config.env"), "HOST=home\nPORT=1")
+	writeFile(t, filepath.Join(xdg, "app", "config.env"), "HOST=xdg\nPORT=2")
+	t.Setenv("PORT", "3")
+
+	var host string
+	var port int
+	var vars VarSet
+	vars.Var("HOST", Required, StringVar(&host), "")
+	vars.Var("PORT", Required, IntVar(&port), "")
+	if err := vars.ParseWithConfigFile("app"); err != nil {
+		t.Fatal(err)
+	}
+	if host != "xdg" || port != 3 {
+		t.Errorf("host=%q port=%d, wanted the first file overridden by the environment", host, port)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDotenvInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "common", "base.env"), "A=base\nB=base\ninclude ../shared.env")
+	writeFile(t, filepath.Join(dir, "shared.env"), "C=shared")
+	writeFile(t, filepath.Join(dir, "app.env"), "A=app\ninclude 'common/base.env'\nB=app")
+
+	got, err := ReadDotenvFile(filepath.Join(dir, "app.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"A": "base", "B": "app", "C": "shared"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+
+	got, err = ParseDotenv(strings.NewReader("include=x"), filepath.Join(dir, "x.env"))
+	if err != nil || got["include"] != "x" {
+		t.Errorf("got %q, %v, wanted a variable named include", got, err)
+	}
+}
+
+func TestDotenvIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "self.env"), "A=1\ninclude self.env")
+	writeFile(t, filepath.Join(dir, "a.env"), "include b.env")
+	writeFile(t, filepath.Join(dir, "b.env"), "B=1\ninclude ./a.env")
+	writeFile(t, filepath.Join(dir, "missing.env"), "include nope.env")
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"self.env", "self.env:2: include cycle: " + filepath.Join(dir, "self.env") + " -> " + filepath.Join(dir, "self.env")},
+		{"a.env", "b.env:2: include cycle: " + filepath.Join(dir, "a.env") + " -> " + filepath.Join(dir, "b.env") + " -> " + filepath.Join(dir, "a.env")},
+		{"missing.env", "missing.env:1: cannot include: "},
+	}
+	for _, tt := range tests {
+		_, err := ReadDotenvFile(filepath.Join(dir, tt.file))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, wanted %q", tt.file, err, tt.want)
+		}
+	}
+}
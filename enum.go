@@ -0,0 +1,68 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EnumTableVar returns a value that accepts the keys of table and stores
+// the corresponding typed constants into v, e.g. for a `type Mode int`:
+//
+//	EnumTableVar(&mode, map[string]Mode{"dev": ModeDev, "prod": ModeProd})
+//
+// String maps the current value back to its key; when several keys map to
+// the same value, the alphabetically first one is used.
+func EnumTableVar[T comparable](v *T, table map[string]T) *EnumTable[T] {
+	return &EnumTable[T]{v, table}
+}
+
+type EnumTable[T comparable] struct {
+	v     *T
+	table map[string]T
+}
+
+func (v *EnumTable[T]) String() string {
+	if v.v == nil {
+		return ""
+	}
+	keys := v.keys()
+	for _, k := range keys {
+		if v.table[k] == *v.v {
+			return k
+		}
+	}
+	return fmt.Sprint(*v.v)
+}
+
+func (v *EnumTable[T]) Get() interface{} {
+	return *v.v
+}
+
+func (v *EnumTable[T]) Set(raw string) error {
+	p, ok := v.table[raw]
+	if !ok {
+		return fmt.Errorf("must be one of %s", strings.Join(v.keys(), ", "))
+	}
+	*v.v = p
+	return nil
+}
+
+func (v *EnumTable[T]) keys() []string {
+	keys := make([]string, 0, len(v.table))
+	for k := range v.table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *EnumTable[T]) Clone() flag.Value {
+	c := *v.v
+	return &EnumTable[T]{&c, v.table}
+}
+
+func (v *EnumTable[T]) TypeName() string {
+	return "enum"
+}
@@ -0,0 +1,94 @@
+package envloader
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Env is an environment read once, e.g. at startup, to be parsed by several
+// VarSets via TryParseEnv. An Env is never modified, so it can be shared
+// between goroutines.
+type Env struct {
+	m       map[string]string
+	environ []string
+	folded  map[string]string
+}
+
+// NewEnvFromOS captures the current process environment.
+func NewEnvFromOS() *Env {
+	return NewEnvFromSlice(os.Environ())
+}
+
+// NewEnvFromSlice reads KEY=VALUE entries in the format returned by os.Environ,
+// following the same rules as TryParseEnviron.
+func NewEnvFromSlice(environ []string) *Env {
+	return &Env{m: environMap(environ), environ: environ}
+}
+
+// NewEnvFromFile reads a dotenv-style file, see ParseDotenv for the format.
+func NewEnvFromFile(path string) (*Env, error) {
+	m, err := ReadDotenvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	environ := make([]string, 0, len(keys))
+	for _, key := range keys {
+		environ = append(environ, key+"="+m[key])
+	}
+	return &Env{m: m, environ: environ}, nil
+}
+
+// CaseFold returns a copy of the environment whose Lookup falls back to
+// matching keys case-insensitively when there is no exact match.
+// When several keys fold to the same one, the first of them wins.
+func (env *Env) CaseFold() *Env {
+	c := *env
+	c.folded = make(map[string]string, len(env.m))
+	for _, entry := range env.environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		folded := strings.ToLower(key)
+		if _, found := c.folded[folded]; !found {
+			c.folded[folded] = value
+		}
+	}
+	return &c
+}
+
+// Lookup returns the value of the given key, and whether it is present.
+func (env *Env) Lookup(key string) (string, bool) {
+	if v, ok := env.m[key]; ok {
+		return v, true
+	}
+	if env.folded != nil {
+		v, ok := env.folded[strings.ToLower(key)]
+		return v, ok
+	}
+	return "", false
+}
+
+// Getenv returns the value of the given key, or an empty string when absent,
+// like os.Getenv, e.g. to pass the environment to TryParseLayered.
+func (env *Env) Getenv(key string) string {
+	v, _ := env.Lookup(key)
+	return v
+}
+
+// Environ returns the entries of the environment in the format of os.Environ.
+func (env *Env) Environ() []string {
+	return append([]string(nil), env.environ...)
+}
+
+// TryParseEnv parses environment variable values from env, like TryParseEnviron,
+// so that several sets can share an environment read once.
+func (vars VarSet) TryParseEnv(env *Env) *Error {
+	return vars.parse(&parser{lookup: env.Lookup, environ: env.environ})
+}
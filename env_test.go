@@ -0,0 +1,100 @@
+package envloader
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvLookup(t *testing.T) {
+	env := NewEnvFromSlice([]string{"HOST=a", "HOST=b", "EMPTY=", "junk", "Mixed=1", "MIXED=2", "mixed=3"})
+	tests := []struct {
+		key     string
+		folded  bool
+		want    string
+		present bool
+	}{
+		{"HOST", false, "a", true},
+		{"EMPTY", false, "", true},
+		{"junk", false, "", false},
+		{"MISSING", false, "", false},
+		{"host", false, "", false},
+		{"host", true, "a", true},
+		{"MIXED", true, "2", true},
+		{"mIxEd", true, "1", true},
+	}
+	for _, tt := range tests {
+		e := env
+		if tt.folded {
+			e = env.CaseFold()
+		}
+		v, ok := e.Lookup(tt.key)
+		if v != tt.want || ok != tt.present {
+			t.Errorf("Lookup(%q), folded=%v = %q, %v, wanted %q, %v", tt.key, tt.folded, v, ok, tt.want, tt.present)
+		}
+		if got := e.Getenv(tt.key); got != tt.want {
+			t.Errorf("Getenv(%q), folded=%v = %q, wanted %q", tt.key, tt.folded, got, tt.want)
+		}
+	}
+	if _, ok := env.Lookup("host"); ok {
+		t.Errorf("CaseFold modified the original Env")
+	}
+}
+
+func TestEnvEnviron(t *testing.T) {
+	environ := []string{"B=2", "A=1"}
+	env := NewEnvFromSlice(environ)
+	got := env.Environ()
+	got[0] = "B=changed"
+	if v := env.Environ()[0]; v != "B=2" {
+		t.Errorf("Environ()[0] = %q after modifying a copy", v)
+	}
+}
+
+func TestNewEnvFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.env")
+	writeFile(t, path, "# comment\nPORT=8080\nHOST=\"example.com\"\n")
+	env, err := NewEnvFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := env.Environ(), []string{"HOST=example.com", "PORT=8080"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Environ() = %q, wanted %q", got, want)
+	}
+
+	if _, err := NewEnvFromFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Errorf("NewEnvFromFile of a missing file succeeded")
+	}
+}
+
+func TestTryParseEnv(t *testing.T) {
+	env := NewEnvFromSlice([]string{"PORT=8080", "HOST=", "PROT=1"})
+	var port int
+	var host string
+	var warnings []string
+	var vars VarSet
+	vars.Var("PORT", Required, IntVar(&port), "")
+	vars.Var("HOST", Required, StringVar(&host), "")
+	vars.Var("PROTO", Optional, NewString(""), "")
+	vars.DetectTypos()
+	vars.OnWarning(func(msg string) { warnings = append(warnings, msg) })
+
+	if e := vars.TryParseEnv(env); e != nil {
+		t.Fatal(e)
+	}
+	if port != 8080 || !vars[1].IsSpecified {
+		t.Errorf("port = %d, HOST specified = %v, wanted 8080 and an empty but present HOST", port, vars[1].IsSpecified)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("warnings = %q, wanted one about PROT", warnings)
+	}
+
+	var other VarSet
+	other.Var("port", Required, IntVar(new(int)), "")
+	if e := other.TryParseEnv(env); e == nil || len(e.MissingVars) != 1 {
+		t.Errorf("got %v, wanted port missing", e)
+	}
+	if e := other.TryParseEnv(env.CaseFold()); e != nil {
+		t.Errorf("got %v with CaseFold", e)
+	}
+}
@@ -2,11 +2,15 @@
 package envloader
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"regexp"
 	"strings"
+	"time"
 )
 
 // Required is a convenient value to pass to VarSet.Add for variables that are always required.
@@ -33,6 +37,94 @@ func WhenFalse(boolVar *bool) func() bool {
 	}
 }
 
+// WhenSet returns a value to pass to VarSet.Add for variables that are required when the given variable has been specified.
+func WhenSet(v *Var) func() bool {
+	v.referenced = true
+	return func() bool {
+		return v.IsSpecified
+	}
+}
+
+// WhenUnset returns a value to pass to VarSet.Add for variables that are required when the given variable has not been specified.
+func WhenUnset(v *Var) func() bool {
+	v.referenced = true
+	return func() bool {
+		return !v.IsSpecified
+	}
+}
+
+// UnlessSet returns a value to pass to VarSet.Add for variables that are required unless the given variable has been specified.
+// It is the same as WhenUnset, and reads better for alternatives like “DB_URL, or else DB_HOST and DB_NAME”.
+func UnlessSet(v *Var) func() bool {
+	return WhenUnset(v)
+}
+
+// WhenEquals returns a value to pass to VarSet.Add for variables that are required when the given variable's value equals want.
+func WhenEquals(v *Var, want string) func() bool {
+	v.referenced = true
+	return func() bool {
+		return v.Value.String() == want
+	}
+}
+
+// WhenEnvIsOneOf returns a value to pass to VarSet.Add for variables that are required when the given variable's value
+// is one of the given values, e.g. WhenEnvIsOneOf(envVar, "staging", "production").
+// Like other Required funcs, it is evaluated after all variables have been parsed,
+// but declaring the gating variable earlier in the set keeps templates readable.
+func WhenEnvIsOneOf(v *Var, values ...string) func() bool {
+	v.referenced = true
+	return func() bool {
+		cur := v.Value.String()
+		for _, want := range values {
+			if cur == want {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WhenNotEmpty returns a value to pass to VarSet.Add for variables that are required when the given variable's value is not empty.
+func WhenNotEmpty(v *Var) func() bool {
+	v.referenced = true
+	return func() bool {
+		return v.Value.String() != ""
+	}
+}
+
+// And returns a func that is true when all of the given funcs are true.
+// And with no arguments is always true, just like Required.
+func And(fns ...func() bool) func() bool {
+	return func() bool {
+		for _, fn := range fns {
+			if !fn() {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a func that is true when any of the given funcs is true.
+// Or with no arguments is always false, just like Optional.
+func Or(fns ...func() bool) func() bool {
+	return func() bool {
+		for _, fn := range fns {
+			if fn() {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a func that negates the given one.
+func Not(fn func() bool) func() bool {
+	return func() bool {
+		return !fn()
+	}
+}
+
 // Var defines a single environment variable.
 type Var struct {
 	EnvKey   string
@@ -41,20 +133,409 @@ type Var struct {
 	Desc     string
 
 	IsSpecified bool
+
+	def        string // Value.String() at declaration time
+	reset      func() // restores the value to the one at declaration time
+	fromFlag   bool   // set via a flag registered by BindFlags
+	example    string
+	indirect   bool
+	hint       string
+	defaultFn  func() string
+	none       string
+	gate       func() bool
+	minLen     int
+	maxLen     int // zero means no limit
+	immutable  bool
+	locked     bool   // immutable and parsed once
+	lockedVal  string // Value.String() when locked
+	lockedFP   string // fingerprint of the value when locked
+	secret     bool
+	hidden     bool
+	frozen     bool // the marker appended by Freeze
+	referenced bool // captured by a Required func or a OneOf, see Clone
+	appendList bool
+	transforms []func(string) (string, error)
+	normalize  func(string) string
+	tags       []string
+
+	section string
+	check   func(vars VarSet, e *Error)
+	apply   func(s *settings)
+}
+
+// isRequired evaluates the Required func, treating nil as Optional.
+func (vr *Var) isRequired() bool {
+	return vr.Required != nil && vr.Required()
+}
+
+func (vr *Var) isMarker() bool {
+	return vr.EnvKey == ""
+}
+
+// SetValue sets the variable programmatically, as if the environment had
+// the given raw value: calls Value.Set and marks the variable as specified.
+// Raw value transformations and resolvers are not applied.
+func (vr *Var) SetValue(raw string) error {
+	err := vr.Value.Set(raw)
+	if err != nil {
+		return newInvalidValue(vr, err, raw)
+	}
+	vr.IsSpecified = true
+	return nil
+}
+
+// MustSet is like SetValue, but panics on error. Handy in tests.
+func (vr *Var) MustSet(raw string) {
+	if err := vr.SetValue(raw); err != nil {
+		panic(err)
+	}
+}
+
+// Interface returns the current value of the variable, typed when the value
+// implements flag.Getter (all values defined by this package do),
+// or as a string returned by String otherwise.
+func (vr *Var) Interface() interface{} {
+	if g, ok := vr.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return vr.Value.String()
+}
+
+// masked replaces the values of Secret variables in the output.
+const masked = "***"
+
+// Secret marks the variable as holding sensitive data, so that its value
+// is masked whenever it is printed or reported.
+func (vr *Var) Secret() *Var {
+	vr.secret = true
+	return vr
+}
+
+// Hidden omits the variable from printed templates and usage, for internal
+// or testing knobs that operators should not see. Hidden variables are still
+// parsed, and still reported when required but missing.
+func (vr *Var) Hidden() *Var {
+	vr.hidden = true
+	return vr
+}
+
+// Tags adds categories to the variable, like "networking", for selecting
+// subsets of the set via VarSet.Filter. Unlike sections, a variable can have
+// several tags.
+func (vr *Var) Tags(tags ...string) *Var {
+	vr.tags = append(vr.tags, tags...)
+	return vr
+}
+
+// Indirect makes the raw value of the variable name another environment
+// variable holding the actual value, e.g. DB_PASSWORD_FROM=DB_PASSWORD_SECRET.
+// The referenced variable is looked up in the same source, and must be present.
+// The actual value then goes through resolvers and transformations as usual,
+// so combined with FileContentsVar, the referenced variable holds the file path.
+func (vr *Var) Indirect() *Var {
+	vr.indirect = true
+	return vr
+}
+
+// DefaultFunc sets a function computing the default value of the variable
+// from the previously declared ones, like a METRICS_PORT defaulting to
+// HTTP_PORT + 1. When the variable is not specified, parsing calls fn and
+// passes the result to Set, unless it is empty; the variable still does not
+// count as specified. A computed default that fails to parse, or a panic in fn,
+// is reported as an invalid value.
+func (vr *Var) DefaultFunc(fn func() string) *Var {
+	vr.defaultFn = fn
+	return vr
+}
+
+// NoneSentinel sets a raw value, like NONE or -, that explicitly clears
+// the variable, e.g. PROXY_URL=NONE to disable a proxy configured by default.
+// The variable then counts as specified, but holds the zero value of its type.
+func (vr *Var) NoneSentinel(s string) *Var {
+	vr.none = s
+	return vr
+}
+
+// clear resets the value to the zero value of its type, like "" or 0.
+// Values wrapping a pointer to another variable (like Pattern) are cleared
+// via Set(""), and report an error if they do not accept an empty string.
+func (vr *Var) clear() error {
+	if rv := reflect.ValueOf(vr.Value); rv.Kind() == reflect.Pointer && rv.Elem().Kind() != reflect.Struct {
+		rv.Elem().SetZero()
+		return nil
+	}
+	if err := vr.Value.Set(""); err != nil {
+		return fmt.Errorf("cannot be cleared: %w", err)
+	}
+	return nil
+}
+
+// MaxLen rejects raw values longer than n bytes before parsing them, guarding
+// against accidents like pasting a whole file into a variable.
+func (vr *Var) MaxLen(n int) *Var {
+	vr.maxLen = n
+	return vr
+}
+
+// MinLen rejects raw values shorter than n bytes before parsing them.
+func (vr *Var) MinLen(n int) *Var {
+	vr.minLen = n
+	return vr
+}
+
+func (vr *Var) checkLen(raw string) error {
+	if vr.maxLen > 0 && len(raw) > vr.maxLen {
+		return fmt.Errorf("value is %d bytes long, at most %d allowed", len(raw), vr.maxLen)
+	}
+	if len(raw) < vr.minLen {
+		return fmt.Errorf("value is %d bytes long, at least %d required", len(raw), vr.minLen)
+	}
+	return nil
+}
+
+// Immutable makes the variable keep its first parsed value: when the set is
+// parsed again, e.g. on a config reload, a different value is reported as
+// an error and the bound variable is left untouched. Use it for identity-bearing
+// settings like a cluster name or a data directory.
+func (vr *Var) Immutable() *Var {
+	vr.immutable = true
+	return vr
+}
+
+// Hint sets a short note, like a link to internal docs, that PrintError
+// appends to the variable's line when the variable is missing.
+func (vr *Var) Hint(s string) *Var {
+	vr.hint = s
+	return vr
+}
+
+// Example sets an illustrative value, like db.internal.example.com, printed
+// by PrintTo instead of the generic placeholder when the variable has no value.
+// The example is never parsed.
+func (vr *Var) Example(s string) *Var {
+	vr.example = s
+	return vr
+}
+
+// displayValue returns the value to print, masking it for Secret variables.
+// Values implementing Redacted() string, like DSN, mask only the sensitive part.
+func (vr *Var) displayValue() string {
+	s := vr.Value.String()
+	if vr.secret && s != "" {
+		if r, ok := vr.Value.(interface{ Redacted() string }); ok {
+			return r.Redacted()
+		}
+		return masked
+	}
+	return s
+}
+
+// Append makes parsing add the elements of the raw value to the current
+// contents of a comma-separated list value, like StringSlice or IntSlice,
+// instead of replacing them. This allows merging several keys into one slice:
+//
+//	vars.Var("PLUGINS", Optional, StringSliceVar(&plugins), "")
+//	vars.Var("EXTRA_PLUGINS", Optional, StringSliceVar(&plugins), "").Append()
+//
+// When PLUGINS is not set, EXTRA_PLUGINS is appended to the default contents.
+// Use RequireAtLeastOne to require any of the keys.
+func (vr *Var) Append() *Var {
+	vr.appendList = true
+	return vr
+}
+
+// TrimSpace makes the variable strip leading and trailing whitespace
+// from the raw value before parsing it. A value consisting only of whitespace
+// is still present, and becomes empty. See also VarSet.TrimSpace.
+func (vr *Var) TrimSpace() *Var {
+	vr.transforms = append(vr.transforms, func(raw string) (string, error) {
+		return strings.TrimSpace(raw), nil
+	})
+	return vr
+}
+
+// Unescape makes the variable interpret escape sequences in the raw value
+// before parsing it, so that multi-line values like PEM keys fit into a single
+// line: \n becomes a newline, \t a tab, and \\ a single backslash. A backslash
+// followed by any other character, or ending the value, is kept as is.
+func (vr *Var) Unescape() *Var {
+	vr.transforms = append(vr.transforms, func(raw string) (string, error) {
+		return unescape(raw), nil
+	})
+	return vr
+}
+
+func unescape(raw string) string {
+	if !strings.Contains(raw, `\`) {
+		return raw
+	}
+	var buf strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c == '\\' && i+1 < len(raw) {
+			switch raw[i+1] {
+			case 'n':
+				buf.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				buf.WriteByte('\t')
+				i++
+				continue
+			case '\\':
+				buf.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// TrimCutset makes the variable strip all leading and trailing characters
+// contained in cutset from the raw value before parsing it, via strings.Trim.
+//
+// Raw value transformations run in the order the corresponding options
+// have been applied to the variable.
+func (vr *Var) TrimCutset(cutset string) *Var {
+	vr.transforms = append(vr.transforms, func(raw string) (string, error) {
+		return strings.Trim(raw, cutset), nil
+	})
+	return vr
+}
+
+// Transform adds a function that rewrites the raw value before parsing,
+// e.g. filepath.Abs to turn a path absolute. Returned errors are reported
+// as invalid values.
+//
+// Transform, TrimSpace, TrimCutset and Unescape form a single pipeline that
+// runs in the order these options have been applied, after resolving
+// references (see VarSet.SetResolver) and set-wide VarSet.TrimSpace.
+func (vr *Var) Transform(fn func(raw string) (string, error)) *Var {
+	vr.transforms = append(vr.transforms, fn)
+	return vr
+}
+
+// Normalize adds a function that canonicalizes the raw value right before Set,
+// e.g. strings.ToLower to let an Enum or Mapped value accept LOG_LEVEL=Info
+// and INFO. Unlike Transform, it is meant for the matching step: it runs last,
+// after the Transform pipeline, including TrimSpace of both the variable
+// and the set, so the value it gets is already trimmed.
+func (vr *Var) Normalize(fn func(raw string) string) *Var {
+	vr.normalize = fn
+	return vr
+}
+
+// Format makes the variable only accept raw values of the given shape, like
+// svc-<env>-<int>, where each <token> stands for a sub-pattern and the rest is
+// matched literally. Rejected values are reported as "must look like svc-<env>-<int>".
+// The tokens and the regular expressions they compile to are:
+//
+//	<word>   [A-Za-z]+
+//	<alnum>  [A-Za-z0-9]+
+//	<ident>  [A-Za-z_][A-Za-z0-9_]*
+//	<env>    [a-z][a-z0-9]*, e.g. dev, staging or prod
+//	<int>    [0-9]+
+//	<hex>    [0-9a-fA-F]+
+//
+// The check is a step of the Transform pipeline. Panics on unknown tokens.
+func (vr *Var) Format(pattern string) *Var {
+	re := compileFormat(pattern)
+	vr.transforms = append(vr.transforms, func(raw string) (string, error) {
+		if !re.MatchString(raw) {
+			return "", fmt.Errorf("must look like %s", pattern)
+		}
+		return raw, nil
+	})
+	return vr
+}
+
+var formatTokens = map[string]string{
+	"word":  `[A-Za-z]+`,
+	"alnum": `[A-Za-z0-9]+`,
+	"ident": `[A-Za-z_][A-Za-z0-9_]*`,
+	"env":   `[a-z][a-z0-9]*`,
+	"int":   `[0-9]+`,
+	"hex":   `[0-9a-fA-F]+`,
+}
+
+func compileFormat(pattern string) *regexp.Regexp {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for rest := pattern; rest != ""; {
+		before, after, found := strings.Cut(rest, "<")
+		buf.WriteString(regexp.QuoteMeta(before))
+		if !found {
+			break
+		}
+		token, tail, ok := strings.Cut(after, ">")
+		sub := formatTokens[token]
+		if !ok || sub == "" {
+			panic(fmt.Sprintf("envloader: unknown token <%s> in format %q", token, pattern))
+		}
+		buf.WriteString(sub)
+		rest = tail
+	}
+	buf.WriteString("$")
+	return regexp.MustCompile(buf.String())
+}
+
+func (vr *Var) transform(raw string) (string, error) {
+	for _, fn := range vr.transforms {
+		var err error
+		raw, err = fn(raw)
+		if err != nil {
+			return "", err
+		}
+	}
+	if vr.normalize != nil {
+		raw = vr.normalize(raw)
+	}
+	return raw, nil
 }
 
 // VarSet is a slice of environment variable definitions. The ordering matters,
 // both when printing the values (obviously), and also when parsing, because
 // later variables can refer to the values of prior ones.
+//
+// Besides variables, a VarSet contains marker entries for sections, constraints
+// and set-wide settings like CaseInsensitive or Freeze. Markers have an empty
+// EnvKey and a Value that rejects Set, so code ranging over a set should skip
+// the entries with an empty EnvKey.
+//
+// A VarSet is not safe for concurrent use. Parsing sets IsSpecified on the Vars
+// and writes into the bound Go variables, so it must not overlap with another
+// parse of the same set, nor with code reading those variables. To reload
+// configuration while it is in use, parse a Clone of the set (whose values own
+// their storage) and publish the result once parsing succeeds.
 type VarSet []*Var
 
+func (vars VarSet) lookup(envKey string) *Var {
+	for _, vr := range vars {
+		if !vr.isMarker() && vr.EnvKey == envKey {
+			return vr
+		}
+	}
+	return nil
+}
+
+func (vars VarSet) mustLookup(envKey string) *Var {
+	vr := vars.lookup(envKey)
+	if vr == nil {
+		panic(fmt.Sprintf("envloader: unknown variable %s", envKey))
+	}
+	return vr
+}
+
 // Var adds a given value to the set of environment variable definitions.
 //
 // Required func specifies the conditions when the value is required. Very often,
 // a feature is enabled by a master on/off variable, and a bunch of configuration
 // parameters are only required when it is on. Use Required, Optional, WhenTrue
 // and WhenFalse helpers, or define your own custom function. These functions
-// can refer to the values of the previously defined variables.
+// can refer to the values of the previously defined variables. A nil func
+// is the same as Optional.
 //
 // Use StringVar, BoolVar, IntVar & similar helpers defined in this package
 // to make flag.Value for your variables.
@@ -65,10 +546,119 @@ func (vars *VarSet) Var(envKey string, required func() bool, value flag.Value, d
 		Value:    value,
 		Desc:     desc,
 	}
+	if value != nil {
+		v.def = value.String()
+		v.reset = valueState(value)
+	}
+	vars.checkNotFrozen()
 	*vars = append(*vars, v)
 	return v
 }
 
+// VarIf is like Var, but only declares the variable when enabled is true,
+// e.g. for debug-only variables. When enabled is false, it returns a detached
+// Var that is not part of the set, so options can still be applied to it
+// without effect, and it never appears in output or errors.
+func (vars *VarSet) VarIf(enabled bool, envKey string, required func() bool, value flag.Value, desc string) *Var {
+	if !enabled {
+		return &Var{
+			EnvKey:   envKey,
+			Required: required,
+			Value:    value,
+			Desc:     desc,
+		}
+	}
+	return vars.Var(envKey, required, value, desc)
+}
+
+// Merge concatenates the given sets into one, preserving the order of their
+// entries, so that cross-references between variables keep working and
+// constraints, hooks and settings carry over. The shared Vars are not copied.
+// Merge panics if the sets declare the same EnvKey more than once.
+func Merge(sets ...VarSet) VarSet {
+	var result VarSet
+	seen := make(map[string]bool)
+	var frozen bool
+	for _, set := range sets {
+		frozen = frozen || set.isFrozen()
+		for _, vr := range set {
+			if !vr.isMarker() {
+				if seen[vr.EnvKey] {
+					panic(fmt.Sprintf("envloader: Merge: duplicate variable %s", vr.EnvKey))
+				}
+				seen[vr.EnvKey] = true
+			}
+			result = append(result, vr)
+		}
+	}
+	if frozen {
+		result.Freeze()
+	}
+	return result
+}
+
+// StringVar declares a string variable bound to v. It is a shorthand for
+// vars.Var(envKey, required, StringVar(v), desc).
+func (vars *VarSet) StringVar(envKey string, required func() bool, v *string, desc string) *Var {
+	return vars.Var(envKey, required, StringVar(v), desc)
+}
+
+// IntVar declares an int variable bound to v.
+func (vars *VarSet) IntVar(envKey string, required func() bool, v *int, desc string) *Var {
+	return vars.Var(envKey, required, IntVar(v), desc)
+}
+
+// BoolVar declares a bool variable bound to v.
+func (vars *VarSet) BoolVar(envKey string, required func() bool, v *bool, desc string) *Var {
+	return vars.Var(envKey, required, BoolVar(v), desc)
+}
+
+// DurationVar declares a time.Duration variable bound to v.
+func (vars *VarSet) DurationVar(envKey string, required func() bool, v *time.Duration, desc string) *Var {
+	return vars.Var(envKey, required, DurationVar(v), desc)
+}
+
+// Section starts a new group of variables, labeled with the given title
+// in the printed output. Sections do not affect parsing.
+func (vars *VarSet) Section(title string) {
+	vars.addMarker(&Var{section: title})
+}
+
+// Filter returns a view of the set with only the variables tagged with tag,
+// in their original order, e.g. to print a focused subset via PrintTo or Describe.
+// The view shares the Vars of the set, along with its settings and the sections
+// that contain matching variables; constraints are left out. Parse the full set
+// rather than the view, which does not know about the other variables.
+func (vars VarSet) Filter(tag string) VarSet {
+	var result VarSet
+	var section *Var
+	for _, vr := range vars {
+		switch {
+		case vr.section != "":
+			section = vr
+		case vr.apply != nil || vr.frozen:
+			result = append(result, vr)
+		case vr.isMarker():
+		case vr.hasTag(tag):
+			if section != nil {
+				result = append(result, section)
+				section = nil
+			}
+			result = append(result, vr)
+		}
+	}
+	return result
+}
+
+func (vr *Var) hasTag(tag string) bool {
+	for _, t := range vr.tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // String returns a shell script that defines all variables in the set.
 // Variable descriptions are added as comments.
 func (vars VarSet) String() string {
@@ -85,21 +675,124 @@ func (vars VarSet) Print() {
 
 // PrintTo prints a shell script that defines all variables in the set.
 // Variable descriptions are added as comments.
+//
+// Hidden variables are omitted.
 func (vars VarSet) PrintTo(out io.Writer) {
+	redact := vars.settings().redact
+	for i, vr := range vars {
+		if vr.section != "" {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "# ===== %s =====\n", vr.section)
+		}
+		if vr.isMarker() || vr.hidden {
+			continue
+		}
+		vr.printTemplate(out, false, redact)
+	}
+}
+
+// printTemplate prints the description and the assignments of the variable,
+// followed by the Hint as a trailing comment when withHint is true.
+func (vr *Var) printTemplate(out io.Writer, withHint bool, redact func(string) string) {
+	if vr.Desc != "" {
+		fmt.Fprint(out, "# "+strings.ReplaceAll(vr.Desc, "\n", "\n# ")+"\n")
+	}
+	for _, a := range vr.assignments(redact) {
+		if withHint && vr.hint != "" {
+			fmt.Fprintf(out, "%s=%s  # %s\n", a[0], vr.placeholder(a[1]), vr.hint)
+		} else {
+			fmt.Fprintf(out, "%s=%s\n", a[0], vr.placeholder(a[1]))
+		}
+	}
+}
+
+// PrintOverridesTo is like PrintTo, but only prints the variables that have
+// been customized: those whose value differs from the one they had when
+// declared, and those without a default that have been specified.
+// Use it to review the effective configuration or to produce a lean .env file.
+func (vars VarSet) PrintOverridesTo(out io.Writer) {
+	redact := vars.settings().redact
+	var section string
+	printed := false
 	for _, vr := range vars {
-		usage := vr.Desc
-		if usage != "" {
-			usage = "# " + strings.ReplaceAll(usage, "\n", "\n# ") + "\n"
+		if vr.section != "" {
+			section = vr.section
+		}
+		if vr.isMarker() || vr.hidden || !vr.isOverridden() {
+			continue
+		}
+		if section != "" {
+			if printed {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "# ===== %s =====\n", section)
+			section = ""
+		}
+		vr.printTemplate(out, false, redact)
+		printed = true
+	}
+}
+
+func (vr *Var) isOverridden() bool {
+	if vr.Value.String() != vr.def {
+		return true
+	}
+	return vr.def == "" && vr.IsSpecified
+}
+
+// assignments returns the key/value pairs to print for the variable,
+// masking the values of Secret variables and passing the rest through redact,
+// if not nil.
+func (vr *Var) assignments(redact func(string) string) [][2]string {
+	if mv, ok := vr.Value.(multiVar); ok {
+		result := mv.assignments(vr.EnvKey)
+		for i := range result {
+			if vr.secret && result[i][1] != "" {
+				result[i][1] = masked
+			} else if redact != nil {
+				result[i][1] = redact(result[i][1])
+			}
 		}
+		return result
+	}
+	v := vr.displayValue()
+	if redact != nil && !vr.secret {
+		v = redact(v)
+	}
+	return [][2]string{{vr.EnvKey, v}}
+}
 
-		valueStr := vr.Value.String()
-		if valueStr == "" {
-			valueStr = "..."
+// PrintUsageTo prints a flag.PrintDefaults-style description of all variables
+// in the set, marking the ones that are currently required.
+func (vars VarSet) PrintUsageTo(out io.Writer) {
+	for _, vr := range vars {
+		if vr.section != "" {
+			fmt.Fprintf(out, "\n%s:\n", vr.section)
+		}
+		if vr.isMarker() || vr.hidden {
+			continue
 		}
+		var suffix string
+		if vr.isRequired() {
+			suffix = " (required)"
+		}
+		fmt.Fprintf(out, "  %s%s\n", vr.EnvKey, suffix)
+		if vr.Desc != "" {
+			fmt.Fprintf(out, "    \t%s\n", strings.ReplaceAll(vr.Desc, "\n", "\n    \t"))
+		}
+	}
+}
 
-		fmt.Fprintf(out, "%s%s=%s\n", usage, vr.EnvKey, valueStr)
-		// fmt.Fprintf(out, "  %s\n    \t%s\n", vr.EnvKey, strings.ReplaceAll(usage.String(), "\n", "\n    \t"))
+func (vr *Var) placeholder(valueStr string) string {
+	if valueStr == "" {
+		if vr.example != "" {
+			return vr.example
+		}
+		return "..."
 	}
+	return valueStr
 }
 
 // Parse parses the current environment variable values. If parsing fails,
@@ -114,64 +807,457 @@ func (vars VarSet) Parse() {
 
 // TryParse parses the current environment variable values.
 // Returns nil when successful, a pointer to Error when not.
+//
+// A variable that is present in the environment with an empty value counts
+// as specified and gets Set to an empty string, so PORT= fails to parse into
+// an IntVar, and an empty value of a Required variable satisfies it. Earlier
+// versions treated empty values as absent; to keep that, unset such variables
+// or call TryParseFrom(os.Getenv). Parse behaves the same.
 func (vars VarSet) TryParse() *Error {
-	return vars.TryParseFrom(os.Getenv)
+	return vars.TryParseFromLookup(os.LookupEnv)
+}
+
+// ParseMap parses environment variable values from the given map. If parsing fails,
+// prints an error message and exits the program with error code 2.
+func (vars VarSet) ParseMap(m map[string]string) {
+	e := vars.TryParseMap(m)
+	if e != nil {
+		PrintError(e, os.Stderr)
+		os.Exit(2)
+	}
+}
+
+// TryParseMap parses environment variable values from the given map.
+// Returns nil when successful, a pointer to Error when not.
+// Keys mapped to empty strings are present, see TryParse.
+func (vars VarSet) TryParseMap(m map[string]string) *Error {
+	return vars.TryParseFromLookup(func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	})
 }
 
 // TryParseFrom parses environment variable values returned by the given function.
 // Returns nil when successful, a pointer to Error when not.
+//
+// Because getenv cannot distinguish empty values from absent ones,
+// empty values are treated as absent. Use TryParseFromLookup to tell them apart.
 func (vars VarSet) TryParseFrom(getenv func(string) string) *Error {
-	var e *Error
+	return vars.parse(&parser{lookup: getenvLookup(getenv)})
+}
 
-	for _, vr := range vars {
-		raw := getenv(vr.EnvKey)
-		if raw != "" {
-			err := vr.Value.Set(raw)
-			if err != nil {
-				if e == nil {
-					e = &Error{}
-				}
-				e.InvalidValues = append(e.InvalidValues, &InvalidValue{vr.EnvKey, err})
-				continue
+// Mode controls how TryParseWithMode treats missing required variables.
+type Mode int
+
+const (
+	// Strict reports missing required variables as errors, like TryParseFrom.
+	Strict Mode = iota
+
+	// Lenient reports missing required variables as warnings (see OnWarning)
+	// and leaves them at their defaults, for a degraded but running service,
+	// e.g. in development. Invalid values and constraint violations are still errors.
+	Lenient
+)
+
+// TryParseWithMode is like TryParseFrom, but allows choosing how missing
+// required variables are treated.
+func (vars VarSet) TryParseWithMode(getenv func(string) string, mode Mode) *Error {
+	return vars.parse(&parser{lookup: getenvLookup(getenv), mode: mode})
+}
+
+// TryParseFromLookup parses environment variable values returned by the given
+// function, which works like os.LookupEnv. Variables that are present with
+// an empty value count as specified and get Set to an empty string.
+// Returns nil when successful, a pointer to Error when not.
+func (vars VarSet) TryParseFromLookup(lookup func(string) (string, bool)) *Error {
+	return vars.parse(&parser{lookup: lookup})
+}
+
+// TryParseLayered parses environment variable values from several sources,
+// where each source overrides the ones listed before it: for every variable,
+// the last source returning a non-empty value wins. So pass the base layer first,
+// e.g. TryParseLayered(dotenvLookup, os.Getenv) to let the environment override
+// a file. A variable counts as specified when any layer provides a value.
+func (vars VarSet) TryParseLayered(sources ...func(string) string) *Error {
+	return vars.TryParseFrom(func(key string) string {
+		for i := len(sources) - 1; i >= 0; i-- {
+			if v := sources[i](key); v != "" {
+				return v
 			}
-			vr.IsSpecified = true
+		}
+		return ""
+	})
+}
+
+// TryParseContext is like TryParseFrom, but passes ctx to values implementing
+// ContextSetter, so that slow values like the ones doing I/O can be cancelled.
+// When ctx is done, parsing stops and the returned Error reports the variable
+// that was being parsed.
+func (vars VarSet) TryParseContext(ctx context.Context, getenv func(string) string) *Error {
+	return vars.parse(&parser{ctx: ctx, lookup: getenvLookup(getenv)})
+}
+
+// ContextSetter is implemented by values that want a context.Context
+// when parsed via TryParseContext. Other values are parsed via Set.
+type ContextSetter interface {
+	SetContext(ctx context.Context, raw string) error
+}
+
+// TryParseEnviron parses environment variable values from the given
+// KEY=VALUE entries, in the format returned by os.Environ, without consulting
+// the process environment. Entries without = are ignored; when a key occurs
+// several times, the first occurrence wins, matching os.Getenv.
+//
+// With CaseInsensitive, a key is matched exactly if possible, and otherwise
+// against the first entry whose key is equal under Unicode case folding.
+func (vars VarSet) TryParseEnviron(environ []string) *Error {
+	m := environMap(environ)
+	return vars.parse(&parser{
+		lookup: func(key string) (string, bool) {
+			v, ok := m[key]
+			return v, ok
+		},
+		environ: environ,
+	})
+}
+
+func environMap(environ []string) map[string]string {
+	m := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		if _, found := m[key]; !found {
+			m[key] = value
 		}
 	}
+	return m
+}
+
+// TryParseFromDetailed is like TryParseFrom, but also returns a ParseResult
+// recording the raw value seen for each variable, even for the values that failed
+// to parse. Because getenv cannot enumerate the environment, only the keys
+// declared in the set are recorded.
+func (vars VarSet) TryParseFromDetailed(getenv func(string) string) (*Error, *ParseResult) {
+	res := &ParseResult{}
+	e := vars.parse(&parser{lookup: getenvLookup(getenv), result: res})
+	return e, res
+}
+
+// TryParseExplain is like TryParseFrom, but also returns where the value
+// of each variable came from, keyed by EnvKey, with values like SourceEnv,
+// SourceResolver, SourceFlag, SourceDefault and SourceAbsent, e.g. for a
+// configuration audit page. The values themselves are not included.
+func (vars VarSet) TryParseExplain(getenv func(string) string) (*Error, map[string]string) {
+	e, res := vars.TryParseFromDetailed(getenv)
+	sources := make(map[string]string, len(res.Vars))
+	for _, r := range res.Vars {
+		sources[r.Var.EnvKey] = r.Source
+	}
+	return e, sources
+}
+
+// ParseResult records the outcome of parsing each variable of a VarSet.
+type ParseResult struct {
+	Vars []*VarResult
+}
+
+// VarResult records the outcome of parsing a single variable.
+type VarResult struct {
+	Var *Var
+
+	// Raw is the value seen in the environment, masked for Secret variables.
+	Raw string
+
+	// Present is true when the environment has provided a value.
+	Present bool
+
+	// Defaulted is true when the variable has not been provided
+	// and has kept its default value.
+	Defaulted bool
+
+	// Source tells where the value came from, one of the Source constants like SourceEnv.
+	Source string
 
+	// Err is an *InvalidValue for values that failed to parse,
+	// or a *MissingValue for missing required variables.
+	Err error
+}
+
+// MissingRequired returns the keys of the variables that are currently required
+// but have not been specified, taking conditional requirements and constraints
+// like RequireTogether into account. Call after parsing.
+func (vars VarSet) MissingRequired() []string {
+	e := &Error{}
+	vars.validate(e)
+	var keys []string
+	for _, vr := range e.MissingVars {
+		keys = append(keys, vr.EnvKey)
+	}
+	return keys
+}
+
+// ProvisionReport lists the keys of a VarSet by how their values were provided.
+type ProvisionReport struct {
+	// Explicit lists variables that were specified in the environment.
+	Explicit []string
+	// Defaulted lists optional variables that were not specified and kept their default values.
+	Defaulted []string
+	// Missing lists required variables that were not specified.
+	Missing []string
+}
+
+// ProvisionReport reports which variables have been specified explicitly,
+// which fell back to their defaults and which are missing. Call after parsing.
+// The report only includes keys and never exposes the values.
+func (vars VarSet) ProvisionReport() ProvisionReport {
+	var r ProvisionReport
 	for _, vr := range vars {
-		if !vr.IsSpecified && vr.Required() {
-			if e == nil {
-				e = &Error{}
-			}
-			e.MissingVars = append(e.MissingVars, vr)
+		if vr.isMarker() {
+			continue
+		}
+		if vr.IsSpecified {
+			r.Explicit = append(r.Explicit, vr.EnvKey)
+		} else if vr.isRequired() {
+			r.Missing = append(r.Missing, vr.EnvKey)
+		} else {
+			r.Defaulted = append(r.Defaulted, vr.EnvKey)
 		}
 	}
-
-	return e
+	return r
 }
 
 // Error describes environment variable problems encountered by TryParse.
 type Error struct {
 	InvalidValues []*InvalidValue
 	MissingVars   VarSet
+
+	// MissingReasons explains why some of MissingVars are required,
+	// keyed by EnvKey, when that follows from a constraint like RequireTogether.
+	MissingReasons map[string]string
+
+	// ConstraintErrors lists violations of cross-variable constraints,
+	// like the ones registered via VarSet.Constrain and RequireAtLeastOne.
+	ConstraintErrors []error
+
+	// UnknownKeys lists environment keys rejected by VarSet.StrictPrefix.
+	UnknownKeys []string
+
+	// HookErrors lists errors returned by VarSet.AfterParse hooks.
+	HookErrors []error
+
+	// SourceErrors lists problems reading the sources of values,
+	// like a malformed config file.
+	SourceErrors []error
+
+	renderMissing func(vr *Var) string
+	redact        func(s string) string
+	name          string
+}
+
+// Error renders the same text as PrintError, so that Error can be returned
+// and wrapped like any other error.
+func (e *Error) Error() string {
+	var buf strings.Builder
+	PrintError(e, &buf)
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// Unwrap returns the individual problems, for use with errors.Is and errors.As:
+// an *InvalidValue for every invalid value, a *MissingValue for every missing
+// variable, followed by the constraint, hook and source errors.
+func (e *Error) Unwrap() []error {
+	var errs []error
+	for _, iv := range e.InvalidValues {
+		errs = append(errs, iv)
+	}
+	for _, vr := range e.MissingVars {
+		errs = append(errs, &MissingValue{vr.EnvKey})
+	}
+	errs = append(errs, e.ConstraintErrors...)
+	errs = append(errs, e.HookErrors...)
+	errs = append(errs, e.SourceErrors...)
+	return errs
+}
+
+func (e *Error) isEmpty() bool {
+	return len(e.InvalidValues) == 0 && len(e.MissingVars) == 0 && len(e.ConstraintErrors) == 0 && len(e.UnknownKeys) == 0 && len(e.HookErrors) == 0 && len(e.SourceErrors) == 0
+}
+
+func (e *Error) addMissing(vr *Var, reason string) {
+	for _, mv := range e.MissingVars {
+		if mv == vr {
+			return
+		}
+	}
+	e.MissingVars = append(e.MissingVars, vr)
+	if reason != "" {
+		if e.MissingReasons == nil {
+			e.MissingReasons = make(map[string]string)
+		}
+		e.MissingReasons[vr.EnvKey] = reason
+	}
+}
+
+// MergeErrors combines errors returned by parsing several sets, e.g. the ones
+// of different sub-commands, into one that PrintError renders together.
+// Invalid and missing variables are de-duplicated by key, keeping the first
+// occurrence. The merged error keeps the Name of the sets only when they all
+// share it. Nil errors are skipped; returns nil when all errors are nil.
+func MergeErrors(errs ...*Error) *Error {
+	result := &Error{}
+	invalid := make(map[string]bool)
+	missing := make(map[string]bool)
+	unknown := make(map[string]bool)
+	first := true
+	for _, e := range errs {
+		if e == nil {
+			continue
+		}
+		for _, iv := range e.InvalidValues {
+			if !invalid[iv.EnvKey] {
+				invalid[iv.EnvKey] = true
+				result.InvalidValues = append(result.InvalidValues, iv)
+			}
+		}
+		for _, vr := range e.MissingVars {
+			if !missing[vr.EnvKey] {
+				missing[vr.EnvKey] = true
+				result.addMissing(vr, e.MissingReasons[vr.EnvKey])
+			}
+		}
+		for _, key := range e.UnknownKeys {
+			if !unknown[key] {
+				unknown[key] = true
+				result.UnknownKeys = append(result.UnknownKeys, key)
+			}
+		}
+		result.ConstraintErrors = append(result.ConstraintErrors, e.ConstraintErrors...)
+		result.HookErrors = append(result.HookErrors, e.HookErrors...)
+		result.SourceErrors = append(result.SourceErrors, e.SourceErrors...)
+		if result.renderMissing == nil {
+			result.renderMissing = e.renderMissing
+		}
+		if result.redact == nil {
+			result.redact = e.redact
+		}
+		if first {
+			result.name = e.name
+		} else if result.name != e.name {
+			result.name = ""
+		}
+		first = false
+	}
+	if result.isEmpty() {
+		return nil
+	}
+	return result
 }
 
 // PrintError performs default printing of the given error returned by TryParse.
 func PrintError(e *Error, w io.Writer) {
+	b := e.bullet()
+	for _, err := range e.SourceErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
 	for _, iv := range e.InvalidValues {
-		fmt.Fprintf(w, "** %s\n", iv.Error())
+		fmt.Fprintf(w, "%s%s\n", b, iv.Error())
+	}
+	for _, err := range e.ConstraintErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	for _, key := range e.UnknownKeys {
+		fmt.Fprintf(w, "%sunknown environment variable %s\n", b, key)
 	}
+	for _, err := range e.HookErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	for _, vr := range e.MissingVars {
+		if reason := e.MissingReasons[vr.EnvKey]; reason != "" {
+			fmt.Fprintf(w, "%s%s is required because %s\n", b, vr.EnvKey, reason)
+		}
+	}
+	var missing strings.Builder
+	e.printMissing(&missing)
 	if len(e.MissingVars) > 1 {
-		fmt.Fprintf(w, "** missing values for the following %d environment variables:\n%s\n", len(e.MissingVars), e.MissingVars.String())
+		fmt.Fprintf(w, "%smissing values for the following %d environment variables:\n%s\n", b, len(e.MissingVars), missing.String())
 	} else if len(e.MissingVars) == 1 {
-		fmt.Fprintf(w, "** missing value for the following environment variable:\n%s\n", e.MissingVars.String())
+		fmt.Fprintf(w, "%smissing value for the following environment variable:\n%s\n", b, missing.String())
+	}
+}
+
+func (e *Error) printMissing(out io.Writer) {
+	for _, vr := range e.MissingVars {
+		if e.renderMissing != nil {
+			text := e.renderMissing(vr)
+			if text != "" && !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			fmt.Fprint(out, text)
+		} else {
+			vr.printTemplate(out, true, e.redact)
+		}
+	}
+}
+
+// bullet returns the prefix of error lines, including the name of the set, if any.
+func (e *Error) bullet() string {
+	if e.name != "" {
+		return "[" + e.name + "] ** "
+	}
+	return "** "
+}
+
+// PrintErrorCompact prints the given error returned by TryParse in a concise form,
+// listing only the keys of the missing variables instead of their full template.
+func PrintErrorCompact(e *Error, w io.Writer) {
+	b := e.bullet()
+	for _, err := range e.SourceErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	for _, iv := range e.InvalidValues {
+		fmt.Fprintf(w, "%s%s\n", b, iv.Error())
+	}
+	for _, err := range e.ConstraintErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	if len(e.UnknownKeys) > 0 {
+		fmt.Fprintf(w, "%sunknown variables: %s\n", b, strings.Join(e.UnknownKeys, ", "))
+	}
+	for _, err := range e.HookErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	if len(e.MissingVars) > 0 {
+		keys := make([]string, 0, len(e.MissingVars))
+		for _, vr := range e.MissingVars {
+			keys = append(keys, vr.EnvKey)
+		}
+		fmt.Fprintf(w, "%smissing required variables: %s\n", b, strings.Join(keys, ", "))
 	}
 }
 
 // InvalidValue is an error returned as part of Error struct for environment variable values that failed to parse.
+//
+// For Secret variables, the error message masks any occurrences of the raw value.
 type InvalidValue struct {
 	EnvKey string
 	Cause  error
+
+	sensitive []string
+	redact    func(s string) string
+}
+
+func newInvalidValue(vr *Var, cause error, values ...string) *InvalidValue {
+	iv := &InvalidValue{EnvKey: vr.EnvKey, Cause: cause}
+	if vr.secret {
+		for _, v := range values {
+			if v != "" {
+				iv.sensitive = append(iv.sensitive, v)
+			}
+		}
+	}
+	return iv
 }
 
 func (e *InvalidValue) Unwrap() error {
@@ -179,7 +1265,29 @@ func (e *InvalidValue) Unwrap() error {
 }
 
 func (e *InvalidValue) Error() string {
-	return fmt.Sprintf("invalid value of environment variable %s: %v", e.EnvKey, e.Cause)
+	return fmt.Sprintf("invalid value of environment variable %s: %s", e.EnvKey, e.causeString())
+}
+
+// causeString returns the message of Cause, with sensitive values masked
+// and the redactor applied.
+func (e *InvalidValue) causeString() string {
+	msg := e.Cause.Error()
+	for _, v := range e.sensitive {
+		msg = strings.ReplaceAll(msg, v, masked)
+	}
+	if e.redact != nil {
+		msg = e.redact(msg)
+	}
+	return msg
+}
+
+// MissingValue is an error returned by Error.Unwrap for required variables that have not been specified.
+type MissingValue struct {
+	EnvKey string
+}
+
+func (e *MissingValue) Error() string {
+	return fmt.Sprintf("missing value of environment variable %s", e.EnvKey)
 }
 
 // PrintAction returns flag.Value that can be used with flag.Var to print all environment variables in shell format.
@@ -0,0 +1,98 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNilRequiredIsOptional(t *testing.T) {
+	var x string
+	var vars VarSet
+	vars.Var("X", nil, StringVar(&x), "")
+
+	if err := vars.TryParseFrom(func(string) string { return "" }); err != nil {
+		t.Fatalf("got %v, wanted X to be optional", err)
+	}
+	if missing := vars.MissingRequired(); len(missing) != 0 {
+		t.Errorf("MissingRequired() = %v", missing)
+	}
+	if r := vars.ProvisionReport(); len(r.Missing) != 0 {
+		t.Errorf("ProvisionReport().Missing = %v", r.Missing)
+	}
+	if got := vars.Describe()[0].Required; got != RequirednessOptional {
+		t.Errorf("Describe() requiredness = %q", got)
+	}
+	var buf strings.Builder
+	vars.PrintUsageTo(&buf)
+	if strings.Contains(buf.String(), "required") {
+		t.Errorf("PrintUsageTo printed %q", buf.String())
+	}
+
+	if err := vars.TryParseFrom(func(string) string { return "v" }); err != nil || x != "v" {
+		t.Errorf("got %v, x=%q", err, x)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name string
+		def  []string
+		env  map[string]string
+		want string
+	}{
+		{"default and env", []string{"a"}, map[string]string{"EXTRA_PLUGINS": "b,c"}, "a,b,c"},
+		{"base and env", []string{"a"}, map[string]string{"PLUGINS": "x", "EXTRA_PLUGINS": "y"}, "x,y"},
+		{"empty current", nil, map[string]string{"EXTRA_PLUGINS": "b"}, "b"},
+		{"empty value", []string{"a"}, map[string]string{"EXTRA_PLUGINS": ""}, "a"},
+		{"both empty", nil, map[string]string{"EXTRA_PLUGINS": ""}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugins := tt.def
+			var vars VarSet
+			vars.Var("PLUGINS", Optional, StringSliceVar(&plugins), "")
+			extra := vars.Var("EXTRA_PLUGINS", Optional, StringSliceVar(&plugins), "").Append()
+			err := vars.TryParseFromLookup(func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(plugins, ","); got != tt.want {
+				t.Errorf("got %q, wanted %q", got, tt.want)
+			}
+			if !extra.IsSpecified {
+				t.Errorf("EXTRA_PLUGINS is not specified")
+			}
+		})
+	}
+}
+
+func TestVarIfDisabled(t *testing.T) {
+	var host, pprof string
+	var vars VarSet
+	vars.Var("HOST", Required, StringVar(&host), "server host")
+	vars.VarIf(false, "PPROF_ADDR", Required, StringVar(&pprof), "pprof listen address").Secret().Lazy()
+
+	e := vars.TryParseFrom(func(string) string { return "" })
+	if e == nil || len(e.MissingVars) != 1 || e.MissingVars[0].EnvKey != "HOST" {
+		t.Fatalf("got %v, wanted only HOST missing", e)
+	}
+	var out strings.Builder
+	PrintError(e, &out)
+	vars.PrintTo(&out)
+	vars.PrintUsageTo(&out)
+	if strings.Contains(out.String(), "PPROF_ADDR") {
+		t.Errorf("output mentions PPROF_ADDR:\n%s", out.String())
+	}
+	for _, info := range vars.Describe() {
+		if info.Key == "PPROF_ADDR" {
+			t.Errorf("Describe() includes PPROF_ADDR")
+		}
+	}
+
+	if err := vars.TryParseFrom(func(key string) string { return key }); err != nil || pprof != "" {
+		t.Errorf("got %v, pprof=%q, wanted PPROF_ADDR ignored", err, pprof)
+	}
+}
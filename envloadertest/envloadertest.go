@@ -0,0 +1,34 @@
+// Package envloadertest helps integration tests that need configuration
+// from the environment.
+package envloadertest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andreyvit/envloader"
+)
+
+// Parse parses the current environment into vars, skipping the test when
+// required variables are missing, and failing it when values are invalid
+// or other checks fail. Call it at the start of integration tests:
+//
+//	func TestDatabase(t *testing.T) {
+//		envloadertest.Parse(t, vars)
+//		...
+//	}
+func Parse(t testing.TB, vars envloader.VarSet) {
+	t.Helper()
+	e := vars.TryParse()
+	if e == nil {
+		return
+	}
+	if len(e.MissingVars) > 0 && len(e.InvalidValues) == 0 && len(e.ConstraintErrors) == 0 && len(e.UnknownKeys) == 0 && len(e.SourceErrors) == 0 {
+		keys := make([]string, 0, len(e.MissingVars))
+		for _, vr := range e.MissingVars {
+			keys = append(keys, vr.EnvKey)
+		}
+		t.Skipf("missing configuration: %s", strings.Join(keys, ", "))
+	}
+	t.Fatalf("invalid configuration:\n%v", e)
+}
@@ -0,0 +1,90 @@
+package envloadertest
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/andreyvit/envloader"
+)
+
+// fakeT records how Parse ends the test. Like testing.T, Skipf and Fatalf
+// stop the calling goroutine.
+type fakeT struct {
+	testing.TB
+	skipped, failed string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Skipf(format string, args ...interface{}) {
+	t.skipped = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failed = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func run(vars envloader.VarSet) *fakeT {
+	ft := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Parse(ft, vars)
+	}()
+	<-done
+	return ft
+}
+
+func TestParse(t *testing.T) {
+	t.Setenv("ENVLOADERTEST_PORT", "8080")
+	t.Setenv("ENVLOADERTEST_BAD", "abc")
+	for _, key := range []string{"ENVLOADERTEST_A", "ENVLOADERTEST_B"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	tests := []struct {
+		name            string
+		declare         func(vars *envloader.VarSet)
+		skipped, failed string
+	}{
+		{"ok", func(vars *envloader.VarSet) {
+			vars.Var("ENVLOADERTEST_PORT", envloader.Required, envloader.IntVar(new(int)), "")
+		}, "", ""},
+		{"missing", func(vars *envloader.VarSet) {
+			vars.Var("ENVLOADERTEST_A", envloader.Required, envloader.NewString(""), "")
+			vars.Var("ENVLOADERTEST_B", envloader.Required, envloader.NewString(""), "")
+		}, "missing configuration: ENVLOADERTEST_A, ENVLOADERTEST_B", ""},
+		{"invalid", func(vars *envloader.VarSet) {
+			vars.Var("ENVLOADERTEST_BAD", envloader.Required, envloader.IntVar(new(int)), "")
+		}, "", "ENVLOADERTEST_BAD"},
+		{"missing and invalid", func(vars *envloader.VarSet) {
+			vars.Var("ENVLOADERTEST_A", envloader.Required, envloader.NewString(""), "")
+			vars.Var("ENVLOADERTEST_BAD", envloader.Required, envloader.IntVar(new(int)), "")
+		}, "", "ENVLOADERTEST_A"},
+		{"constraint", func(vars *envloader.VarSet) {
+			vars.Var("ENVLOADERTEST_PORT", envloader.Required, envloader.IntVar(new(int)), "")
+			vars.Constrain(func() error { return fmt.Errorf("boom") })
+		}, "", "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vars envloader.VarSet
+			tt.declare(&vars)
+			ft := run(vars)
+			if ft.skipped != tt.skipped {
+				t.Errorf("skipped with %q, wanted %q", ft.skipped, tt.skipped)
+			}
+			if tt.failed == "" && ft.failed != "" {
+				t.Errorf("failed with %q, wanted no failure", ft.failed)
+			} else if tt.failed != "" && !(strings.HasPrefix(ft.failed, "invalid configuration:\n") && strings.Contains(ft.failed, tt.failed)) {
+				t.Errorf("failed with %q, wanted a message mentioning %q", ft.failed, tt.failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package envloader
+
+import (
+	"flag"
+	"strings"
+)
+
+// BindFlags registers every variable of the set as a flag of fs, named after
+// the key in lower case with dashes (DB_HOST becomes -db-host), with the
+// description as usage. Hidden variables and IndexedStrings are skipped.
+//
+// Call it before fs.Parse, and parse the environment afterwards: a variable
+// set via its flag counts as specified (which satisfies Required) and is not
+// looked up in the environment, so flags take precedence over the environment.
+// Raw value transformations and resolvers only apply to environment values.
+func (vars VarSet) BindFlags(fs *flag.FlagSet) {
+	for _, vr := range vars {
+		if vr.isMarker() || vr.hidden {
+			continue
+		}
+		if _, ok := vr.Value.(multiVar); ok {
+			continue
+		}
+		fs.Var(&flagValue{vr}, FlagName(vr.EnvKey), vr.Desc)
+	}
+}
+
+// FlagName returns the name of the flag BindFlags registers for the given key.
+func FlagName(envKey string) string {
+	return strings.ReplaceAll(strings.ToLower(envKey), "_", "-")
+}
+
+// AsArgs returns the variables that are specified or differ from their defaults
+// as command-line arguments like --db-host=localhost, the inverse of BindFlags,
+// e.g. for spawning a child process configured via flags. Each argument is prefix,
+// typically "--" or "-", followed by the FlagName of the key, i.e. the key in lower
+// case with underscores replaced by dashes, then = and the value. Hidden variables
+// and IndexedStrings are skipped, like in BindFlags.
+//
+// Values of Secret variables are masked; use AsArgsUnmasked to include them.
+func (vars VarSet) AsArgs(prefix string) []string {
+	return vars.asArgs(prefix, (*Var).displayValue)
+}
+
+// AsArgsUnmasked is like AsArgs, but includes the actual values of Secret variables.
+func (vars VarSet) AsArgsUnmasked(prefix string) []string {
+	return vars.asArgs(prefix, func(vr *Var) string {
+		return vr.Value.String()
+	})
+}
+
+func (vars VarSet) asArgs(prefix string, value func(vr *Var) string) []string {
+	var args []string
+	for _, vr := range vars {
+		if vr.isMarker() || vr.hidden || !vr.isOverridden() {
+			continue
+		}
+		if _, ok := vr.Value.(multiVar); ok {
+			continue
+		}
+		args = append(args, prefix+FlagName(vr.EnvKey)+"="+value(vr))
+	}
+	return args
+}
+
+type flagValue struct {
+	vr *Var
+}
+
+func (f *flagValue) String() string {
+	if f.vr == nil {
+		return ""
+	}
+	return f.vr.displayValue()
+}
+
+func (f *flagValue) Get() interface{} {
+	if g, ok := f.vr.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return f.vr.Value.String()
+}
+
+func (f *flagValue) Set(raw string) error {
+	if err := f.vr.Value.Set(raw); err != nil {
+		return err
+	}
+	f.vr.IsSpecified = true
+	f.vr.fromFlag = true
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	switch v := f.vr.Value.(type) {
+	case *Bool, *OnOffBool, *InvertedBool, *FlagBool:
+		return true
+	case interface{ IsBoolFlag() bool }:
+		return v.IsBoolFlag()
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestBindFlags(t *testing.T) {
+	var host, token string
+	var port int
+	var verbose bool
+	var vars VarSet
+	vars.Var("DB_HOST", Required, StringVar(&host), "database host")
+	vars.Var("DB_PORT", Optional, IntVar(&port), "")
+	vars.Var("API_TOKEN", Required, StringVar(&token), "")
+	vars.Var("VERBOSE", Optional, BoolVar(&verbose), "")
+	vars.Var("INTERNAL", Optional, NewString(""), "").Hidden()
+	vars.IndexedStrings("UPSTREAM_", new([]string))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	vars.BindFlags(fs)
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	if want := "[api-token db-host db-port verbose]"; fmt.Sprint(names) != want {
+		t.Errorf("flags = %v, wanted %s", names, want)
+	}
+	if usage := fs.Lookup("db-host").Usage; usage != "database host" {
+		t.Errorf("usage = %q", usage)
+	}
+
+	if err := fs.Parse([]string{"-db-host=flaghost", "-verbose"}); err != nil {
+		t.Fatal(err)
+	}
+	e, sources := vars.TryParseExplain(mapGetenv(map[string]string{
+		"DB_HOST": "envhost",
+		"DB_PORT": "5432",
+	}))
+	if e == nil || len(e.MissingVars) != 1 || e.MissingVars[0].EnvKey != "API_TOKEN" {
+		t.Fatalf("got %v, wanted only API_TOKEN missing", e)
+	}
+	if host != "flaghost" || port != 5432 || !verbose {
+		t.Errorf("host=%q port=%d verbose=%v, wanted the flag to take precedence", host, port, verbose)
+	}
+	if sources["DB_HOST"] != SourceFlag || sources["DB_PORT"] != SourceEnv {
+		t.Errorf("sources = %v", sources)
+	}
+
+	if err := fs.Parse([]string{"-db-port=abc"}); err == nil {
+		t.Errorf("invalid flag value accepted")
+	}
+}
+
+func mapGetenv(m map[string]string) func(string) string {
+	return func(key string) string { return m[key] }
+}
+
+func TestFlagName(t *testing.T) {
+	for key, want := range map[string]string{"DB_HOST": "db-host", "PORT": "port", "Mixed_Case_1": "mixed-case-1"} {
+		if got := FlagName(key); got != want {
+			t.Errorf("FlagName(%q) = %q, wanted %q", key, got, want)
+		}
+	}
+}
+
+func TestAsArgs(t *testing.T) {
+	var vars VarSet
+	vars.Var("DB_HOST", Optional, NewString("localhost"), "")
+	vars.Var("DB_PORT", Optional, IntVar(new(int)), "")
+	vars.Var("DB_NAME", Optional, NewString(""), "")
+	vars.Var("DB_PASSWORD", Optional, NewString(""), "").Secret()
+	vars.Var("INTERNAL", Optional, NewString(""), "").Hidden()
+	vars.IndexedStrings("UPSTREAM_", new([]string))
+
+	e := vars.TryParseMap(map[string]string{
+		"DB_HOST":     "localhost",
+		"DB_PORT":     "5432",
+		"DB_NAME":     "",
+		"DB_PASSWORD": "s3cret",
+		"INTERNAL":    "x",
+		"UPSTREAM_0":  "a",
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want string
+	}{
+		{"masked", vars.AsArgs("--"), "[--db-port=5432 --db-name= --db-password=***]"},
+		{"unmasked", vars.AsArgsUnmasked("-"), "[-db-port=5432 -db-name= -db-password=s3cret]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(tt.got); got != tt.want {
+			t.Errorf("%s: got %s, wanted %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// GitRefInfo is a git ref name or commit SHA, as returned by GitRef.Get.
+type GitRefInfo struct {
+	Name  string
+	IsSHA bool // Name is a (possibly abbreviated) commit SHA
+}
+
+// GitRefVar returns a value that accepts git commit SHAs (7 to 40 hex digits)
+// and branch or tag names valid per git check-ref-format, like main or release/1.2.
+// Values are stored as given. Get reports hex strings of SHA length as SHAs,
+// like git does when resolving them; spell a branch with such a name in full,
+// like refs/heads/deadbeef, to refer to the branch.
+func GitRefVar(v *string) *GitRef {
+	return (*GitRef)(v)
+}
+
+type GitRef string
+
+func (v GitRef) String() string {
+	return string(v)
+}
+
+func (v GitRef) Get() interface{} {
+	return GitRefInfo{string(v), isGitSHA(string(v))}
+}
+
+func (v *GitRef) Set(raw string) error {
+	if err := checkRefName(raw); err != nil {
+		return err
+	}
+	*v = GitRef(raw)
+	return nil
+}
+
+func (v *GitRef) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *GitRef) TypeName() string {
+	return "gitref"
+}
+
+func isGitSHA(s string) bool {
+	if len(s) < 7 || len(s) > 40 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRefName validates a ref name according to the rules of git check-ref-format.
+func checkRefName(s string) error {
+	switch {
+	case s == "":
+		return fmt.Errorf("ref name must not be empty")
+	case s == "@":
+		return fmt.Errorf("ref name must not be @")
+	case strings.HasPrefix(s, "/") || strings.HasSuffix(s, "/"):
+		return fmt.Errorf("ref name must not start or end with a slash")
+	case strings.HasSuffix(s, "."):
+		return fmt.Errorf("ref name must not end with a dot")
+	case strings.Contains(s, ".."):
+		return fmt.Errorf("ref name must not contain ..")
+	case strings.Contains(s, "//"):
+		return fmt.Errorf("ref name must not contain consecutive slashes")
+	case strings.Contains(s, "@{"):
+		return fmt.Errorf("ref name must not contain @{")
+	}
+	for _, c := range s {
+		if c < 0x20 || c == 0x7f || strings.ContainsRune(" ~^:?*[\\", c) {
+			return fmt.Errorf("ref name must not contain %q", c)
+		}
+	}
+	for _, comp := range strings.Split(s, "/") {
+		if strings.HasPrefix(comp, ".") || strings.HasSuffix(comp, ".lock") {
+			return fmt.Errorf("ref name components must not start with a dot or end with .lock")
+		}
+	}
+	return nil
+}
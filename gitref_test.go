@@ -0,0 +1,56 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGitRef(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		raw   string
+		isSHA bool
+		err   string
+	}{
+		{"main", false, ""},
+		{"release/1.2", false, ""},
+		{"v1.2.3", false, ""},
+		{"abc1234", true, ""},
+		{sha, true, ""},
+		{strings.ToUpper(sha), true, ""},
+		{"refs/heads/" + sha, false, ""},
+		{"abc123", false, ""},    // too short for a SHA, a valid branch name
+		{sha + "8", false, ""},   // too long for a SHA
+		{"deadbeefx", false, ""}, // not hex
+		{"", false, "must not be empty"},
+		{"@", false, "must not be @"},
+		{"/main", false, "must not start or end with a slash"},
+		{"main.", false, "must not end with a dot"},
+		{"a..b", false, "must not contain .."},
+		{"a//b", false, "consecutive slashes"},
+		{"a@{1}", false, "must not contain @{"},
+		{"a b", false, `must not contain ' '`},
+		{"a~1", false, `must not contain '~'`},
+		{"feature/.hidden", false, "must not start with a dot"},
+		{"main.lock", false, "end with .lock"},
+	}
+	for _, tt := range tests {
+		var s string
+		v := GitRefVar(&s)
+		err := v.Set(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.raw, err)
+			continue
+		}
+		info := v.Get().(GitRefInfo)
+		if s != tt.raw || info.Name != tt.raw || info.IsSHA != tt.isSHA {
+			t.Errorf("%q: stored %q, got %+v, wanted IsSHA=%v", tt.raw, s, info, tt.isSHA)
+		}
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/andreyvit/envloader
 
-go 1.19
+go 1.21
@@ -0,0 +1,47 @@
+package envloader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImmutableFileContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pw")
+	os.WriteFile(path, []byte("one"), 0o600)
+
+	var pw string
+	var vars VarSet
+	vars.Var("PW_FILE", Required, FileContentsVar(&pw), "").Immutable()
+	env := map[string]string{"PW_FILE": path}
+	if err := vars.TryParseMap(env); err != nil {
+		t.Fatal(err)
+	}
+	if err := vars.TryParseMap(env); err != nil {
+		t.Fatalf("reparse of the same value failed: %v", err)
+	}
+
+	os.WriteFile(path, []byte("two"), 0o600)
+	err := vars.TryParseMap(env)
+	if err == nil || !strings.Contains(err.Error(), "cannot change immutable value") {
+		t.Fatalf("got %v, wanted an immutability error", err)
+	}
+	if pw != "one" {
+		t.Errorf("pw = %q, wanted the locked value", pw)
+	}
+}
+
+func TestImmutableString(t *testing.T) {
+	var name string
+	var vars VarSet
+	vars.Var("CLUSTER", Required, StringVar(&name), "").Immutable()
+	vars.TryParseMap(map[string]string{"CLUSTER": "a"})
+	err := vars.TryParseMap(map[string]string{"CLUSTER": "b"})
+	if err == nil || !strings.Contains(err.Error(), `from "a" to "b"`) {
+		t.Fatalf("got %v", err)
+	}
+	if name != "a" {
+		t.Errorf("name = %q, wanted a", name)
+	}
+}
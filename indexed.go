@@ -0,0 +1,230 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// multiVar is implemented by values that are spread over several
+// environment variables derived from the EnvKey of their Var.
+type multiVar interface {
+	scan(envKey string, getenv func(string) string, s *settings) (specified bool, errs []*InvalidValue)
+	assignments(envKey string) [][2]string
+}
+
+// IndexedStrings declares a list that is specified via numbered environment
+// variables prefix+"0", prefix+"1" and so on, like UPSTREAM_0, UPSTREAM_1.
+//
+// Indices must be contiguous and start at zero: parsing stops at the first
+// missing (or empty) index, and any variables after the gap are ignored.
+// When at least one element is found, the found elements replace the contents
+// of dst; otherwise dst is left untouched.
+//
+// The returned Var is optional; adjust its fields to change that or to add a description.
+func (vars *VarSet) IndexedStrings(prefix string, dst *[]string) *Var {
+	return vars.Var(prefix, Optional, &indexedStrings{dst}, "")
+}
+
+type indexedStrings struct {
+	v *[]string
+}
+
+func (v *indexedStrings) String() string {
+	return strings.Join(*v.v, ",")
+}
+
+func (v *indexedStrings) Get() interface{} {
+	return *v.v
+}
+
+func (v *indexedStrings) Set(raw string) error {
+	*v.v = strings.Split(raw, ",")
+	return nil
+}
+
+func (v *indexedStrings) Clone() flag.Value {
+	c := append([]string(nil), *v.v...)
+	return &indexedStrings{&c}
+}
+
+func (v *indexedStrings) TypeName() string {
+	return "[]string"
+}
+
+func (v *indexedStrings) scan(prefix string, getenv func(string) string, _ *settings) (bool, []*InvalidValue) {
+	var items []string
+	for i := 0; ; i++ {
+		raw := getenv(prefix + strconv.Itoa(i))
+		if raw == "" {
+			break
+		}
+		items = append(items, raw)
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+	*v.v = items
+	return true, nil
+}
+
+func (v *indexedStrings) assignments(prefix string) [][2]string {
+	if len(*v.v) == 0 {
+		return [][2]string{{prefix + "0", ""}}
+	}
+	var result [][2]string
+	for i, item := range *v.v {
+		result = append(result, [2]string{prefix + strconv.Itoa(i), item})
+	}
+	return result
+}
+
+// Indexed declares a list of structs that is specified via families of numbered
+// environment variables, like ENDPOINT_0_URL, ENDPOINT_0_TIMEOUT, ENDPOINT_1_URL.
+// For every index, declare is called with a fresh set and element, and declares
+// the variables of the element in sub, with keys relative to prefix+index+"_":
+//
+//	envloader.Indexed(&vars, "ENDPOINT_", &endpoints, func(i int, sub *envloader.VarSet, e *Endpoint) {
+//		sub.Var("URL", envloader.Required, envloader.StringVar(&e.URL), "endpoint URL")
+//		sub.Var("TIMEOUT", envloader.Optional, envloader.DurationVar(&e.Timeout), "request timeout")
+//	})
+//
+// An element exists when any of its variables is set. Like with IndexedStrings,
+// indices must be contiguous and start at zero: parsing stops at the first index
+// without any variables set. The Required funcs of sub only apply to existing
+// elements, so a missing required variable of an element is reported as an invalid
+// value, and constraints of sub are checked per element. When at least one element
+// is found and all of them are valid, the elements replace the contents of dst;
+// otherwise dst is left untouched. Elements are parsed with the resolvers,
+// TrimSpace and case insensitivity of vars, in addition to those declared in sub.
+//
+// Printing shows the values of the elements as last parsed, masking the Secret
+// variables of sub; declare is called with fresh elements only, so it can set
+// their defaults. The returned Var is optional; adjust its fields to change that or to add a description.
+func Indexed[T any](vars *VarSet, prefix string, dst *[]T, declare func(i int, sub *VarSet, elem *T)) *Var {
+	return vars.Var(prefix, Optional, &indexedStructs[T]{v: dst, declare: declare}, "")
+}
+
+type indexedStructs[T any] struct {
+	v       *[]T
+	declare func(i int, sub *VarSet, elem *T)
+	printed [][2]string // relative keys and display values of the parsed elements
+}
+
+func (v *indexedStructs[T]) String() string {
+	var items []string
+	for _, a := range v.assignments("") {
+		items = append(items, a[0]+"="+a[1])
+	}
+	return strings.Join(items, " ")
+}
+
+func (v *indexedStructs[T]) Get() interface{} {
+	return *v.v
+}
+
+func (v *indexedStructs[T]) Set(raw string) error {
+	return fmt.Errorf("cannot be set directly, set the numbered variables instead")
+}
+
+func (v *indexedStructs[T]) Clone() flag.Value {
+	c := append([]T(nil), *v.v...)
+	return &indexedStructs[T]{&c, v.declare, v.printed}
+}
+
+func (v *indexedStructs[T]) TypeName() string {
+	return "[]struct"
+}
+
+func (v *indexedStructs[T]) scan(prefix string, getenv func(string) string, s *settings) (bool, []*InvalidValue) {
+	var items []T
+	var printed [][2]string
+	var errs []*InvalidValue
+	for i := 0; ; i++ {
+		elemPrefix := prefix + strconv.Itoa(i) + "_"
+		var elem T
+		sub := VarSet{s.inherited()}
+		v.declare(i, &sub, &elem)
+		exists := false
+		for _, vr := range sub {
+			if !vr.isMarker() && getenv(elemPrefix+vr.EnvKey) != "" {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			break
+		}
+		if e := sub.TryParseFrom(func(key string) string { return getenv(elemPrefix + key) }); e != nil {
+			errs = append(errs, e.prefixed(elemPrefix)...)
+		}
+		items = append(items, elem)
+		printed = append(printed, subAssignments(i, sub)...)
+	}
+	if len(items) == 0 || len(errs) > 0 {
+		return false, errs
+	}
+	*v.v, v.printed = items, printed
+	return true, nil
+}
+
+// inherited returns a marker entry that applies the settings of the enclosing
+// set, which affect how the raw values of Indexed elements are parsed.
+func (s *settings) inherited() *Var {
+	return &Var{apply: func(sub *settings) {
+		sub.trimSpace = s.trimSpace
+		sub.redact = s.redact
+		for scheme, fn := range s.resolvers {
+			if sub.resolvers == nil {
+				sub.resolvers = make(map[string]resolverFunc)
+			}
+			sub.resolvers[scheme] = fn
+		}
+	}}
+}
+
+// prefixed converts the problems of an element of Indexed into invalid values
+// with complete keys.
+func (e *Error) prefixed(prefix string) []*InvalidValue {
+	var result []*InvalidValue
+	for _, iv := range e.InvalidValues {
+		c := *iv
+		c.EnvKey = prefix + iv.EnvKey
+		result = append(result, &c)
+	}
+	for _, vr := range e.MissingVars {
+		result = append(result, &InvalidValue{EnvKey: prefix + vr.EnvKey, Cause: fmt.Errorf("missing value, required by the other %s* variables", prefix)})
+	}
+	var others []error
+	others = append(others, e.ConstraintErrors...)
+	others = append(others, e.HookErrors...)
+	for _, err := range others {
+		result = append(result, &InvalidValue{EnvKey: strings.TrimSuffix(prefix, "_"), Cause: err})
+	}
+	return result
+}
+
+func (v *indexedStructs[T]) assignments(prefix string) [][2]string {
+	printed := v.printed
+	if len(printed) == 0 {
+		var sub VarSet
+		v.declare(0, &sub, new(T))
+		printed = subAssignments(0, sub)
+	}
+	result := make([][2]string, 0, len(printed))
+	for _, a := range printed {
+		result = append(result, [2]string{prefix + a[0], a[1]})
+	}
+	return result
+}
+
+func subAssignments(i int, sub VarSet) [][2]string {
+	var result [][2]string
+	for _, vr := range sub {
+		if !vr.isMarker() && !vr.hidden {
+			result = append(result, [2]string{strconv.Itoa(i) + "_" + vr.EnvKey, vr.displayValue()})
+		}
+	}
+	return result
+}
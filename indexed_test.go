@@ -0,0 +1,32 @@
+package envloader
+
+import "testing"
+
+func TestIndexedInheritsSettings(t *testing.T) {
+	type endpoint struct {
+		URL   string
+		Token string
+	}
+	var endpoints []endpoint
+	var vars VarSet
+	vars.TrimSpace()
+	vars.CaseInsensitive()
+	vars.SetResolver("vault", func(ref string) (string, error) {
+		return "secret-" + ref, nil
+	})
+	Indexed(&vars, "ENDPOINT_", &endpoints, func(i int, sub *VarSet, e *endpoint) {
+		sub.Var("URL", Required, StringVar(&e.URL), "")
+		sub.Var("TOKEN", Optional, StringVar(&e.Token), "")
+	})
+
+	env := map[string]string{
+		"endpoint_0_url":   "  https://example.com\n",
+		"ENDPOINT_0_TOKEN": "vault://api",
+	}
+	if err := vars.TryParseFrom(func(key string) string { return env[key] }); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 1 || endpoints[0].URL != "https://example.com" || endpoints[0].Token != "secret-api" {
+		t.Errorf("got %+v", endpoints)
+	}
+}
@@ -0,0 +1,96 @@
+package envloader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ParseInteractive parses the current environment like TryParse, but when
+// the only problem is missing required variables and in is a terminal, it
+// prompts for their values on out instead of failing, re-prompting until
+// the value is valid. Input of Secret variables is not echoed (best-effort,
+// via stty). When in is not a terminal, or the input ends, the error is
+// returned as usual.
+func (vars VarSet) ParseInteractive(in io.Reader, out io.Writer) *Error {
+	e := vars.TryParse()
+	if e == nil || !isTerminal(in) {
+		return e
+	}
+	return vars.promptMissing(e, in, out)
+}
+
+// promptMissing is the interactive part of ParseInteractive, which reparses
+// after each round of prompts, since requiredness can depend on the answers.
+func (vars VarSet) promptMissing(e *Error, in io.Reader, out io.Writer) *Error {
+	r := bufio.NewReader(in)
+	for attempt := 0; attempt <= len(vars) && e != nil && e.onlyMissing(); attempt++ {
+		for _, vr := range e.MissingVars {
+			if !vr.prompt(r, in, out) {
+				return e
+			}
+		}
+		e = vars.TryParse()
+	}
+	return e
+}
+
+func (e *Error) onlyMissing() bool {
+	rest := *e
+	rest.MissingVars = nil
+	return len(e.MissingVars) > 0 && rest.isEmpty()
+}
+
+// prompt asks for the value of the variable until Set accepts it,
+// returning false when the input ends.
+func (vr *Var) prompt(r *bufio.Reader, in io.Reader, out io.Writer) bool {
+	if vr.Desc != "" {
+		fmt.Fprintf(out, "# %s\n", strings.ReplaceAll(vr.Desc, "\n", "\n# "))
+	}
+	for {
+		fmt.Fprintf(out, "%s: ", vr.EnvKey)
+		if vr.secret {
+			setEcho(in, false)
+		}
+		line, err := r.ReadString('\n')
+		if vr.secret {
+			setEcho(in, true)
+			fmt.Fprintln(out)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if err != nil && line == "" {
+			return false
+		}
+		if err := vr.SetValue(line); err != nil {
+			fmt.Fprintf(out, "** %v\n", err)
+			continue
+		}
+		return true
+	}
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func setEcho(in io.Reader, on bool) {
+	f, ok := in.(*os.File)
+	if !ok {
+		return
+	}
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = f
+	cmd.Run()
+}
@@ -0,0 +1,70 @@
+package envloader
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func unsetenv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestPromptMissing(t *testing.T) {
+	unsetenv(t, "ENVLOADER_TEST_PORT", "ENVLOADER_TEST_TOKEN", "ENVLOADER_TEST_TLS", "ENVLOADER_TEST_CERT")
+	var port int
+	var token, cert string
+	var tls bool
+	var vars VarSet
+	vars.Var("ENVLOADER_TEST_PORT", Required, IntVar(&port), "port to listen on")
+	vars.Var("ENVLOADER_TEST_TOKEN", Required, StringVar(&token), "").Secret()
+	vars.Var("ENVLOADER_TEST_TLS", Required, BoolVar(&tls), "")
+	vars.Var("ENVLOADER_TEST_CERT", WhenTrue(&tls), StringVar(&cert), "")
+
+	in := strings.NewReader("abc\n8080\ns3cret\r\ntrue\ncert.pem\n")
+	var out strings.Builder
+	e := vars.promptMissing(vars.TryParse(), in, &out)
+	if e != nil {
+		t.Fatalf("got %v\noutput:\n%s", e, out.String())
+	}
+	if port != 8080 || token != "s3cret" || !tls || cert != "cert.pem" {
+		t.Errorf("port=%d token=%q tls=%v cert=%q", port, token, tls, cert)
+	}
+	want := "# port to listen on\n" +
+		"ENVLOADER_TEST_PORT: ** invalid value of environment variable ENVLOADER_TEST_PORT: strconv.ParseInt: parsing \"abc\": invalid syntax\n" +
+		"ENVLOADER_TEST_PORT: ENVLOADER_TEST_TOKEN: \n" +
+		"ENVLOADER_TEST_TLS: ENVLOADER_TEST_CERT: "
+	if got := out.String(); got != want {
+		t.Errorf("output:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestPromptMissingEOF(t *testing.T) {
+	unsetenv(t, "ENVLOADER_TEST_A", "ENVLOADER_TEST_B")
+	var vars VarSet
+	vars.Var("ENVLOADER_TEST_A", Required, NewString(""), "")
+	vars.Var("ENVLOADER_TEST_B", Required, NewString(""), "")
+
+	e := vars.promptMissing(vars.TryParse(), strings.NewReader("a"), new(strings.Builder))
+	if e == nil || len(e.MissingVars) != 2 {
+		t.Fatalf("got %v, wanted the original error", e)
+	}
+	if got := vars[0].Value.String(); got != "a" {
+		t.Errorf("A = %q, wanted the last line without a newline", got)
+	}
+}
+
+func TestParseInteractiveNotTerminal(t *testing.T) {
+	unsetenv(t, "ENVLOADER_TEST_A")
+	var vars VarSet
+	vars.Var("ENVLOADER_TEST_A", Required, NewString(""), "")
+	var out strings.Builder
+	e := vars.ParseInteractive(strings.NewReader("a\n"), &out)
+	if e == nil || len(e.MissingVars) != 1 || out.Len() != 0 {
+		t.Errorf("got %v, printed %q, wanted the error without prompts", e, out.String())
+	}
+}
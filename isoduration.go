@@ -0,0 +1,155 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ISODurationVar returns a value that parses ISO 8601 durations like PT1H30M,
+// P1DT2H or P2W. Days are 24 hours and weeks are 7 days; years and months
+// are rejected because their length varies. Seconds can have a fraction.
+// String renders the ISO form, use GoFormat to render like time.Duration.
+func ISODurationVar(v *time.Duration) *ISODuration {
+	return &ISODuration{v: v}
+}
+
+type ISODuration struct {
+	v      *time.Duration
+	goForm bool
+}
+
+// GoFormat makes String render the value like time.Duration, e.g. 1h30m0s.
+func (v *ISODuration) GoFormat() *ISODuration {
+	v.goForm = true
+	return v
+}
+
+func (v *ISODuration) String() string {
+	if v.v == nil {
+		return ""
+	}
+	if v.goForm {
+		return v.v.String()
+	}
+	return formatISODuration(*v.v)
+}
+
+func (v *ISODuration) Get() interface{} {
+	return *v.v
+}
+
+func (v *ISODuration) Set(raw string) error {
+	d, err := parseISODuration(raw)
+	if err != nil {
+		return err
+	}
+	*v.v = d
+	return nil
+}
+
+func (v *ISODuration) Clone() flag.Value {
+	c := *v.v
+	return &ISODuration{&c, v.goForm}
+}
+
+func (v *ISODuration) TypeName() string {
+	return "isoduration"
+}
+
+func (v *ISODuration) saveState() func() {
+	return saveState(v.v)
+}
+
+func parseISODuration(raw string) (time.Duration, error) {
+	s := raw
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if !strings.HasPrefix(s, "P") || len(s) < 2 {
+		return 0, fmt.Errorf("expected an ISO 8601 duration like PT1H30M")
+	}
+	s = s[1:]
+	var total float64
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			if inTime || len(s) == 1 {
+				return 0, fmt.Errorf("invalid ISO 8601 duration")
+			}
+			inTime = true
+			s = s[1:]
+			continue
+		}
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return !(r >= '0' && r <= '9' || r == '.' || r == ',')
+		})
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid ISO 8601 duration")
+		}
+		n, err := strconv.ParseFloat(strings.ReplaceAll(s[:i], ",", "."), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q in ISO 8601 duration", s[:i])
+		}
+		var unit time.Duration
+		switch c := s[i]; {
+		case c == 'Y' && !inTime, c == 'M' && !inTime:
+			return 0, fmt.Errorf("years and months are not supported, because their length varies")
+		case c == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case c == 'H' && inTime:
+			unit = time.Hour
+		case c == 'M' && inTime:
+			unit = time.Minute
+		case c == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("unexpected %q in ISO 8601 duration", c)
+		}
+		total += n * float64(unit)
+		s = s[i+1:]
+	}
+	if total >= math.MaxInt64 {
+		return 0, fmt.Errorf("duration is too long")
+	}
+	d := time.Duration(math.Round(total))
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+func formatISODuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * 24 * time.Hour
+	}
+	if d > 0 {
+		b.WriteByte('T')
+		if h := d / time.Hour; h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+			d -= h * time.Hour
+		}
+		if m := d / time.Minute; m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+			d -= m * time.Minute
+		}
+		if d > 0 {
+			b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestISODuration(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Duration
+		str  string
+		err  string
+	}{
+		{"PT1H30M", 90 * time.Minute, "PT1H30M", ""},
+		{"P1DT2H", 26 * time.Hour, "P1DT2H", ""},
+		{"P2W", 14 * 24 * time.Hour, "P14D", ""},
+		{"PT1.5S", 1500 * time.Millisecond, "PT1.5S", ""},
+		{"PT0,25S", 250 * time.Millisecond, "PT0.25S", ""},
+		{"PT0S", 0, "PT0S", ""},
+		{"-PT5M", -5 * time.Minute, "-PT5M", ""},
+		{"P1D", 24 * time.Hour, "P1D", ""},
+		{"PT36H", 36 * time.Hour, "P1DT12H", ""},
+		{"", 0, "", "expected an ISO 8601 duration"},
+		{"P", 0, "", "expected an ISO 8601 duration"},
+		{"1h", 0, "", "expected an ISO 8601 duration"},
+		{"PT", 0, "", "invalid ISO 8601 duration"},
+		{"P1DT", 0, "", "invalid ISO 8601 duration"},
+		{"P1Y", 0, "", "years and months are not supported"},
+		{"P1M", 0, "", "years and months are not supported"},
+		{"PT1D", 0, "", `unexpected 'D'`},
+		{"P1H", 0, "", `unexpected 'H'`},
+		{"PT1.2.3S", 0, "", `invalid number "1.2.3"`},
+		{"PTH", 0, "", "invalid ISO 8601 duration"},
+		{"P1000000W", 0, "", "duration is too long"},
+	}
+	for _, tt := range tests {
+		var d time.Duration
+		v := ISODurationVar(&d)
+		err := v.Set(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || d != tt.want {
+			t.Errorf("%q: got %v, %v, wanted %v", tt.raw, d, err, tt.want)
+			continue
+		}
+		if got := v.String(); got != tt.str {
+			t.Errorf("%q: String() = %q, wanted %q", tt.raw, got, tt.str)
+		}
+	}
+}
+
+func TestISODurationGoFormat(t *testing.T) {
+	d := 90 * time.Minute
+	if got := ISODurationVar(&d).GoFormat().String(); got != "1h30m0s" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,123 @@
+package envloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// JSONVar returns a value that unmarshals a JSON document into v.
+func JSONVar[T any](v *T) *JSONValue[T] {
+	return &JSONValue[T]{v: v}
+}
+
+type JSONValue[T any] struct {
+	v               *T
+	disallowUnknown bool
+	validator       JSONValidator
+}
+
+// JSONSchemaVar returns a value that accepts JSON documents approved by the
+// given validator, e.g. one checking them against a JSON Schema, and stores
+// them verbatim.
+func JSONSchemaVar(v *json.RawMessage, validator JSONValidator) *JSONValue[json.RawMessage] {
+	return JSONVar(v).Validate(validator)
+}
+
+// JSONValidator validates JSON documents, e.g. against a JSON Schema using
+// a library of your choice. Return a *JSONSchemaError to report where
+// the document is wrong.
+type JSONValidator interface {
+	ValidateJSON(data []byte) error
+}
+
+// JSONValidatorFunc adapts a function to the JSONValidator interface.
+type JSONValidatorFunc func(data []byte) error
+
+func (f JSONValidatorFunc) ValidateJSON(data []byte) error {
+	return f(data)
+}
+
+// JSONSchemaError describes a schema violation at the given location
+// of a JSON document, like /servers/0/port.
+type JSONSchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e *JSONSchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("at %s: %s", e.Path, e.Message)
+}
+
+// Validate makes Set check syntactically valid JSON documents with the given
+// validator before decoding them.
+func (v *JSONValue[T]) Validate(validator JSONValidator) *JSONValue[T] {
+	v.validator = validator
+	return v
+}
+
+// DisallowUnknownFields makes Set reject JSON objects with keys that do not
+// match any field of the destination struct.
+func (v *JSONValue[T]) DisallowUnknownFields() *JSONValue[T] {
+	v.disallowUnknown = true
+	return v
+}
+
+// String renders the value as JSON, and the zero value of T as an empty
+// string rather than null or {}, like other values render their defaults.
+func (v *JSONValue[T]) String() string {
+	if v.v == nil || reflect.ValueOf(v.v).Elem().IsZero() {
+		return ""
+	}
+	data, err := json.Marshal(v.v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func (v *JSONValue[T]) Get() interface{} {
+	return *v.v
+}
+
+func (v *JSONValue[T]) Set(raw string) error {
+	var p T
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	if v.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(&p)
+	if err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	if v.validator != nil {
+		if err := v.validator.ValidateJSON([]byte(raw)); err != nil {
+			return fmt.Errorf("schema violation: %w", err)
+		}
+	}
+	*v.v = p
+	return nil
+}
+
+// Clone copies the current value via a JSON round trip, so that the clone
+// does not share maps, slices or pointers with the original.
+func (v *JSONValue[T]) Clone() flag.Value {
+	c := new(T)
+	if data, err := json.Marshal(v.v); err == nil {
+		_ = json.Unmarshal(data, c)
+	}
+	return &JSONValue[T]{c, v.disallowUnknown, v.validator}
+}
+
+func (v *JSONValue[T]) TypeName() string {
+	return "json"
+}
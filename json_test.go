@@ -0,0 +1,32 @@
+package envloader
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONValueString(t *testing.T) {
+	type config struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	tests := []struct {
+		name string
+		v    interface{ String() string }
+		want string
+	}{
+		{"zero struct", JSONVar(new(config)), ""},
+		{"struct", JSONVar(&config{Name: "a"}), `{"name":"a","port":0}`},
+		{"nil map", JSONVar(new(map[string]int)), ""},
+		{"empty map", JSONVar(&map[string]int{}), "{}"},
+		{"nil slice", JSONVar(new([]string)), ""},
+		{"zero int", JSONVar(new(int)), ""},
+		{"nil raw message", JSONVar(new(json.RawMessage)), ""},
+		{"nil pointer", &JSONValue[int]{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.v.String(); got != tt.want {
+			t.Errorf("%s: String() = %q, wanted %q", tt.name, got, tt.want)
+		}
+	}
+}
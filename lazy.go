@@ -0,0 +1,111 @@
+package envloader
+
+import (
+	"flag"
+	"sync"
+)
+
+// Lazy defers parsing of the variable until its value is first needed,
+// for values that are expensive to set up (like FileContents) and not used
+// on every code path. Parsing only records the raw value and marks the
+// variable as specified; the underlying Value.Set runs on the first call to
+// LazyValue.Get or LazyValue.Resolve, so the bound Go variable is not
+// updated until then. Resolvers registered via SetResolver still run
+// during parsing.
+//
+// Lazy replaces the Value of the variable with a *LazyValue wrapping it,
+// and has no effect on IndexedStrings.
+func (vr *Var) Lazy() *Var {
+	if _, ok := vr.Value.(multiVar); ok {
+		return vr
+	}
+	if _, ok := vr.Value.(*LazyValue); !ok {
+		vr.Value = &LazyValue{v: vr.Value, vr: vr, mu: new(sync.Mutex)}
+		vr.reset = valueState(vr.Value)
+	}
+	return vr
+}
+
+// LazyValue wraps the value of a Lazy variable, and calls its Set with the raw
+// value once, when first needed. String, Get and Resolve are safe for concurrent
+// use once parsing is done; resolution happens at most once per Set, guarded
+// by a mutex.
+type LazyValue struct {
+	v       flag.Value
+	vr      *Var
+	mu      *sync.Mutex
+	raw     string
+	pending bool
+	err     error
+}
+
+// String returns the raw value when it has not been resolved yet,
+// so that printing the set does not trigger resolution.
+func (v *LazyValue) String() string {
+	if v.v == nil {
+		return ""
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.pending {
+		return v.raw
+	}
+	return v.v.String()
+}
+
+// Get resolves the value if needed, and returns the result of the wrapped
+// value's Get (or String, if it does not implement flag.Getter). Use Resolve
+// to find out whether the value is valid.
+func (v *LazyValue) Get() interface{} {
+	v.Resolve()
+	if g, ok := v.v.(flag.Getter); ok {
+		return g.Get()
+	}
+	return v.v.String()
+}
+
+// Resolve calls Set of the wrapped value with the raw value, if it has not
+// been done yet, and returns the resulting error as an *InvalidValue.
+func (v *LazyValue) Resolve() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.pending {
+		if err := v.v.Set(v.raw); err != nil {
+			v.err = newInvalidValue(v.vr, err, v.raw)
+		}
+		v.pending = false
+	}
+	return v.err
+}
+
+// Set records the raw value to be resolved later.
+func (v *LazyValue) Set(raw string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.raw = raw
+	v.pending = true
+	v.err = nil
+	return nil
+}
+
+func (v *LazyValue) Clone() flag.Value {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c := *v
+	if cl, ok := v.v.(Cloner); ok {
+		c.v = cl.Clone()
+	}
+	c.mu = new(sync.Mutex)
+	return &c
+}
+
+// state returns the raw value and whether it is still pending resolution.
+func (v *LazyValue) state() (raw string, pending bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.raw, v.pending
+}
+
+func (v *LazyValue) TypeName() string {
+	return typeName(v.v)
+}
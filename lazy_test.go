@@ -0,0 +1,31 @@
+package envloader
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLazyConcurrent(t *testing.T) {
+	var port int
+	var vars VarSet
+	vars.Var("PORT", Optional, IntVar(&port), "").Lazy()
+	if err := vars.TryParseFrom(func(string) string { return "8080" }); err != nil {
+		t.Fatal(err)
+	}
+	lv := vars[0].Value.(*LazyValue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s := lv.String(); s != "8080" {
+				t.Errorf("String() = %q", s)
+			}
+			if v := lv.Get(); v != 8080 {
+				t.Errorf("Get() = %v", v)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,400 @@
+package envloader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parser holds the options of a single parsing pass.
+type parser struct {
+	ctx     context.Context
+	lookup  func(string) (string, bool)
+	environ []string // the full environment, when available
+	result  *ParseResult
+	records []*VarResult
+	mode    Mode
+	redact  func(s string) string
+}
+
+func (p *parser) record(vr *Var, raw string, present bool, source string, err error) {
+	if vr.secret && raw != "" {
+		raw = masked
+	} else if p.redact != nil {
+		raw = p.redact(raw)
+	}
+	p.records = append(p.records, &VarResult{
+		Var:       vr,
+		Raw:       raw,
+		Present:   present,
+		Defaulted: !present,
+		Source:    source,
+		Err:       err,
+	})
+}
+
+func (vars VarSet) parse(p *parser) *Error {
+	s := vars.settings()
+	e := s.newError()
+	p.redact = s.redact
+	if s.caseInsensitive {
+		if p.environ != nil {
+			p.lookup = foldedEnvironLookup(p.environ, p.lookup)
+		} else {
+			p.lookup = caseInsensitiveLookup(p.lookup)
+		}
+	}
+
+	var progress func(vr *Var)
+	if s.onProgress != nil {
+		progress = vars.progress(s.onProgress)
+	}
+	for _, vr := range vars {
+		if vr.isMarker() {
+			continue
+		}
+		if progress != nil {
+			if err := protect("OnProgress callback", func() error {
+				progress(vr)
+				return nil
+			}); err != nil {
+				e.HookErrors = append(e.HookErrors, err)
+				progress = nil
+			}
+		}
+		if p.ctx != nil {
+			if err := p.ctx.Err(); err != nil {
+				e.InvalidValues = append(e.InvalidValues, &InvalidValue{EnvKey: vr.EnvKey, Cause: fmt.Errorf("parsing interrupted: %w", err)})
+				return e
+			}
+		}
+		if !vr.isActive() {
+			p.record(vr, "", false, SourceAbsent, nil)
+			continue
+		}
+		p.parseVar(vr, s, e)
+		if vr.immutable && !vr.locked && p.records[len(p.records)-1].Err == nil {
+			vr.locked, vr.lockedVal, vr.lockedFP = true, vr.Value.String(), fingerprint(vr.Value)
+		}
+	}
+
+	if p.environ != nil {
+		if s.detectTypos {
+			vars.warnTypos(p.environ, s)
+		}
+		if len(s.prefixes) > 0 {
+			vars.checkPrefixes(p.environ, s, e)
+		}
+	}
+
+	vars.validate(e)
+	p.markMissing(e)
+	if p.mode == Lenient {
+		for _, vr := range e.MissingVars {
+			if reason := e.MissingReasons[vr.EnvKey]; reason != "" {
+				s.warnf("%s is required because %s, continuing without it", vr.EnvKey, reason)
+			} else {
+				s.warnf("missing value of required environment variable %s, continuing without it", vr.EnvKey)
+			}
+		}
+		e.MissingVars, e.MissingReasons = nil, nil
+	}
+
+	if e.isEmpty() {
+		for _, hook := range s.hooks {
+			if err := protect("AfterParse hook", hook); err != nil {
+				e.HookErrors = append(e.HookErrors, err)
+			}
+		}
+	}
+
+	p.finish(s, e)
+
+	if e.isEmpty() {
+		return nil
+	}
+	return e
+}
+
+func (p *parser) parseVar(vr *Var, s *settings, e *Error) {
+	if mv, ok := vr.Value.(multiVar); ok {
+		specified, errs := mv.scan(vr.EnvKey, p.getenv, s)
+		for _, iv := range errs {
+			iv.redact = s.redact
+		}
+		e.InvalidValues = append(e.InvalidValues, errs...)
+		var err error
+		if len(errs) > 0 {
+			err = errs[0]
+		} else if specified {
+			vr.IsSpecified = true
+		}
+		p.record(vr, "", specified, sourceOf(vr, specified, false), err)
+		return
+	}
+
+	if vr.fromFlag {
+		p.record(vr, "", true, SourceFlag, nil)
+		return
+	}
+
+	raw, present := p.lookup(vr.EnvKey)
+	if !present {
+		if vr.defaultFn != nil {
+			var def string
+			err := protect("DefaultFunc", func() error {
+				def = vr.defaultFn()
+				return nil
+			})
+			if err == nil && def != "" {
+				err = protect("Set", func() error {
+					return p.set(vr, def)
+				})
+				if err != nil {
+					err = fmt.Errorf("invalid computed default: %w", err)
+				}
+			}
+			if err != nil {
+				iv := newInvalidValue(vr, err, def)
+				iv.redact = s.redact
+				e.InvalidValues = append(e.InvalidValues, iv)
+				p.record(vr, "", false, SourceDefault, iv)
+				return
+			}
+		}
+		p.record(vr, "", false, sourceOf(vr, false, false), nil)
+		return
+	}
+
+	if vr.none != "" && raw == vr.none {
+		if err := vr.clear(); err != nil {
+			iv := newInvalidValue(vr, err, raw)
+			iv.redact = s.redact
+			e.InvalidValues = append(e.InvalidValues, iv)
+			p.record(vr, raw, true, SourceEnv, iv)
+			return
+		}
+		vr.IsSpecified = true
+		p.record(vr, raw, true, SourceEnv, nil)
+		return
+	}
+
+	var value string
+	var resolved bool
+	err := vr.checkLen(raw)
+	if err == nil && vr.indirect {
+		ref := raw
+		if raw, present = p.lookup(ref); !present {
+			err = fmt.Errorf("references %s, which is not set", ref)
+		}
+	}
+	if err == nil {
+		value, resolved, err = s.resolve(p.ctx, raw)
+	}
+	if err == nil {
+		if s.trimSpace {
+			value = strings.TrimSpace(value)
+		}
+		err = protect("transformation", func() (err error) {
+			value, err = vr.transform(value)
+			return err
+		})
+	}
+	if err == nil && vr.appendList {
+		if cur := vr.Value.String(); cur != "" && value != "" {
+			value = cur + "," + value
+		} else if cur != "" {
+			value = cur
+		}
+	}
+	if err == nil {
+		var restore func()
+		if vr.locked {
+			restore = vr.saveState()
+		}
+		err = protect("Set", func() error {
+			return p.set(vr, value)
+		})
+		if err == nil && vr.locked && fingerprint(vr.Value) != vr.lockedFP {
+			cur := vr.Value.String()
+			restore()
+			if vr.secret || cur == vr.lockedVal {
+				err = fmt.Errorf("cannot change immutable value")
+			} else {
+				err = fmt.Errorf("cannot change immutable value from %q to %q", vr.lockedVal, cur)
+			}
+		}
+	}
+	if err != nil {
+		iv := newInvalidValue(vr, err, raw, value)
+		iv.redact = s.redact
+		e.InvalidValues = append(e.InvalidValues, iv)
+		p.record(vr, raw, true, sourceOf(vr, true, resolved), iv)
+		return
+	}
+	vr.IsSpecified = true
+	p.record(vr, raw, true, sourceOf(vr, true, resolved), nil)
+}
+
+// Sources of variable values reported via ParseEvent and VarResult.
+const (
+	SourceEnv      = "env"      // specified in the environment
+	SourceResolver = "resolver" // specified as a reference fetched via SetResolver
+	SourceFlag     = "flag"     // specified via a flag registered by BindFlags
+	SourceDefault  = "default"  // not specified, keeps a non-empty default value
+	SourceAbsent   = "absent"   // not specified, and has no value
+)
+
+func sourceOf(vr *Var, present, resolved bool) string {
+	switch {
+	case resolved:
+		return SourceResolver
+	case present:
+		return SourceEnv
+	case vr.Value.String() != "":
+		return SourceDefault
+	default:
+		return SourceAbsent
+	}
+}
+
+// markMissing attributes missing values to the records of the pass.
+func (p *parser) markMissing(e *Error) {
+	missing := make(map[*Var]bool, len(e.MissingVars))
+	for _, vr := range e.MissingVars {
+		missing[vr] = true
+	}
+	for _, r := range p.records {
+		if r.Err == nil && missing[r.Var] {
+			r.Err = &MissingValue{r.Var.EnvKey}
+		}
+	}
+}
+
+// finish delivers the records of the pass. A panicking OnParse callback
+// gets no further events, and is reported via Error.HookErrors.
+func (p *parser) finish(s *settings, e *Error) {
+	if p.result != nil {
+		p.result.Vars = p.records
+	}
+	for _, fn := range s.onParse {
+		err := protect("OnParse callback", func() error {
+			for _, r := range p.records {
+				fn(ParseEvent{
+					Key:    r.Var.EnvKey,
+					Source: r.Source,
+					OK:     r.Err == nil,
+					Err:    r.Err,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			e.HookErrors = append(e.HookErrors, err)
+		}
+	}
+}
+
+// ParseEvent describes the outcome of parsing a single variable,
+// as reported to VarSet.OnParse callbacks. It never includes the value.
+type ParseEvent struct {
+	Key    string
+	Source string // one of the Source constants, like SourceEnv
+	OK     bool
+	Err    error // an *InvalidValue or a *MissingValue
+}
+
+// ProgressEvent reports that parsing of a variable is starting, as reported
+// to VarSet.OnProgress callbacks. It never includes the value.
+type ProgressEvent struct {
+	Key     string
+	Index   int // 1-based position among the variables of the set
+	Total   int
+	Elapsed time.Duration // since the parsing started
+}
+
+// String renders the event like "parsing 12/40: DB_PASSWORD (3.2s elapsed)".
+func (ev ProgressEvent) String() string {
+	return fmt.Sprintf("parsing %d/%d: %s (%s elapsed)", ev.Index, ev.Total, ev.Key, ev.Elapsed.Round(100*time.Millisecond))
+}
+
+func (vars VarSet) progress(fn func(ev ProgressEvent)) func(vr *Var) {
+	total := 0
+	for _, vr := range vars {
+		if !vr.isMarker() {
+			total++
+		}
+	}
+	start := time.Now()
+	index := 0
+	return func(vr *Var) {
+		index++
+		fn(ProgressEvent{Key: vr.EnvKey, Index: index, Total: total, Elapsed: time.Since(start)})
+	}
+}
+
+// validate reports missing required variables and constraint violations.
+//
+// Panics in Required funcs and constraints are reported as constraint errors.
+func (vars VarSet) validate(e *Error) {
+	for _, vr := range vars {
+		if vr.isMarker() || vr.IsSpecified || !vr.isActive() {
+			continue
+		}
+		var required bool
+		err := protect("Required func of "+vr.EnvKey, func() error {
+			required = vr.isRequired()
+			return nil
+		})
+		if err != nil {
+			e.ConstraintErrors = append(e.ConstraintErrors, err)
+		} else if required {
+			e.addMissing(vr, "")
+		}
+	}
+
+	for _, vr := range vars {
+		if vr.check != nil {
+			err := protect("constraint", func() error {
+				vr.check(vars, e)
+				return nil
+			})
+			if err != nil {
+				e.ConstraintErrors = append(e.ConstraintErrors, err)
+			}
+		}
+	}
+}
+
+// protect calls fn, converting a panic into an error that says what panicked,
+// so that a misbehaving callback cannot crash parsing.
+func protect(what string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked: %v", what, r)
+		}
+	}()
+	return fn()
+}
+
+// getenv adapts lookup for code that treats empty values as absent.
+func (p *parser) getenv(key string) string {
+	v, _ := p.lookup(key)
+	return v
+}
+
+// getenvLookup adapts a getenv function, treating empty values as absent.
+func getenvLookup(getenv func(string) string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v := getenv(key)
+		return v, v != ""
+	}
+}
+
+func (p *parser) set(vr *Var, raw string) error {
+	if cs, ok := vr.Value.(ContextSetter); ok && p.ctx != nil {
+		return cs.SetContext(p.ctx, raw)
+	}
+	return vr.Value.Set(raw)
+}
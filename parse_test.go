@@ -0,0 +1,75 @@
+package envloader
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDefaultFuncInvalid(t *testing.T) {
+	var port int
+	var vars VarSet
+	vars.Var("PORT", Optional, IntVar(&port), "").DefaultFunc(func() string { return "abc" })
+
+	err := vars.TryParseMap(map[string]string{})
+	if err == nil || len(err.InvalidValues) != 1 || !strings.Contains(err.Error(), "invalid computed default") {
+		t.Fatalf("got %v, wanted an invalid computed default", err)
+	}
+}
+
+func TestDefaultFuncPanic(t *testing.T) {
+	var port int
+	var vars VarSet
+	vars.Var("PORT", Optional, IntVar(&port), "").DefaultFunc(func() string { panic("boom") })
+
+	err := vars.TryParseMap(map[string]string{})
+	if err == nil || len(err.InvalidValues) != 1 || !strings.Contains(err.Error(), "DefaultFunc panicked: boom") {
+		t.Fatalf("got %v, wanted a DefaultFunc panic error", err)
+	}
+}
+
+func TestDefaultFunc(t *testing.T) {
+	httpPort, metricsPort := 8080, 0
+	var vars VarSet
+	vars.Var("HTTP_PORT", Optional, IntVar(&httpPort), "")
+	vars.Var("METRICS_PORT", Optional, IntVar(&metricsPort), "").DefaultFunc(func() string {
+		return strconv.Itoa(httpPort + 1)
+	})
+
+	if err := vars.TryParseMap(map[string]string{"HTTP_PORT": "9000"}); err != nil {
+		t.Fatal(err)
+	}
+	if metricsPort != 9001 || vars[1].IsSpecified {
+		t.Errorf("metricsPort = %d, IsSpecified = %v", metricsPort, vars[1].IsSpecified)
+	}
+}
+
+func TestEmptyVersusAbsent(t *testing.T) {
+	t.Setenv("ENVLOADER_TEST_EMPTY", "")
+	unsetenv(t, "ENVLOADER_TEST_ABSENT")
+	tests := []struct {
+		name    string
+		parse   func(vars VarSet) *Error
+		key     string
+		invalid bool
+	}{
+		{"TryParse empty", VarSet.TryParse, "ENVLOADER_TEST_EMPTY", true},
+		{"TryParse absent", VarSet.TryParse, "ENVLOADER_TEST_ABSENT", false},
+		{"TryParseFrom empty", func(vars VarSet) *Error { return vars.TryParseFrom(os.Getenv) }, "ENVLOADER_TEST_EMPTY", false},
+		{"TryParseFrom absent", func(vars VarSet) *Error { return vars.TryParseFrom(os.Getenv) }, "ENVLOADER_TEST_ABSENT", false},
+	}
+	for _, tt := range tests {
+		port := 8080
+		var vars VarSet
+		vars.Var(tt.key, Optional, IntVar(&port), "")
+		e := tt.parse(vars)
+		if tt.invalid {
+			if e == nil || len(e.InvalidValues) != 1 {
+				t.Errorf("%s: got %v, wanted an invalid value", tt.name, e)
+			}
+		} else if e != nil || port != 8080 || vars[0].IsSpecified {
+			t.Errorf("%s: got %v, port=%d, IsSpecified=%v, wanted the default", tt.name, e, port, vars[0].IsSpecified)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package envloader
+
+import (
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// PEMVar returns a value that decodes PEM-encoded key material, like inline
+// TLS certificates and keys, storing the DER bytes of the first block. The block
+// type must be one of blockTypes, e.g. CERTIFICATE or PRIVATE KEY, which catches
+// swapped or truncated files at startup; with no blockTypes, any type is accepted.
+// Combine with Unescape for PEM given on a single line. String masks the contents.
+func PEMVar(v *[]byte, blockTypes ...string) *PEM {
+	return &PEM{v: v, types: blockTypes}
+}
+
+type PEM struct {
+	v     *[]byte
+	types []string
+	block *pem.Block
+}
+
+// Block returns the decoded PEM block, or nil when unset.
+func (v *PEM) Block() *pem.Block {
+	return v.block
+}
+
+func (v *PEM) String() string {
+	if v.v == nil || len(*v.v) == 0 {
+		return ""
+	}
+	return masked
+}
+
+func (v *PEM) Get() interface{} {
+	return *v.v
+}
+
+func (v *PEM) Set(raw string) error {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(raw)))
+	if block == nil {
+		return fmt.Errorf("no valid PEM block found")
+	}
+	ok := len(v.types) == 0
+	for _, t := range v.types {
+		ok = ok || t == block.Type
+	}
+	if !ok {
+		return fmt.Errorf("expected a PEM block of type %s, got %s", strings.Join(v.types, " or "), block.Type)
+	}
+	*v.v = block.Bytes
+	v.block = block
+	return nil
+}
+
+func (v *PEM) Clone() flag.Value {
+	c := *v.v
+	return &PEM{&c, v.types, v.block}
+}
+
+func (v *PEM) TypeName() string {
+	return "pem"
+}
+
+func (v *PEM) saveState() func() {
+	b, block := *v.v, v.block
+	return func() {
+		*v.v, v.block = b, block
+	}
+}
@@ -0,0 +1,53 @@
+package envloader
+
+import (
+	"bytes"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestPEM(t *testing.T) {
+	cert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("cert")}))
+	key := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}))
+	tests := []struct {
+		name  string
+		types []string
+		raw   string
+		want  string
+		err   string
+	}{
+		{"any type", nil, key, "key", ""},
+		{"matching type", []string{"CERTIFICATE"}, cert, "cert", ""},
+		{"one of types", []string{"RSA PRIVATE KEY", "PRIVATE KEY"}, key, "key", ""},
+		{"surrounding space", nil, "\n  " + cert + "\n\n", "cert", ""},
+		{"first block", nil, cert + key, "cert", ""},
+		{"wrong type", []string{"CERTIFICATE"}, key, "", "expected a PEM block of type CERTIFICATE, got PRIVATE KEY"},
+		{"wrong types", []string{"CERTIFICATE", "PUBLIC KEY"}, key, "", "expected a PEM block of type CERTIFICATE or PUBLIC KEY"},
+		{"not PEM", nil, "hello", "", "no valid PEM block found"},
+		{"truncated", nil, cert[:len(cert)-10], "", "no valid PEM block found"},
+		{"empty", nil, "", "", "no valid PEM block found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b []byte
+			v := PEMVar(&b, tt.types...)
+			err := v.Set(tt.raw)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got error %v, wanted %q", err, tt.err)
+				}
+				if b != nil || v.Block() != nil {
+					t.Errorf("a failed Set stored %q", b)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(b, []byte(tt.want)) {
+				t.Errorf("got %q, %v, wanted %q", b, err, tt.want)
+			}
+			if v.String() != masked || v.Block() == nil {
+				t.Errorf("String() = %q, Block() = %v", v.String(), v.Block())
+			}
+		})
+	}
+}
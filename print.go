@@ -0,0 +1,241 @@
+package envloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PrintExportsTo prints the current values of all variables as shell export
+// statements, single-quoting the values so that the output is safe to source
+// or eval. Values of Secret variables are masked as ***, so sourcing the output
+// sets them to ***; use PrintExportsToUnmasked to pass the configuration on
+// to another process. Hidden variables are omitted.
+func (vars VarSet) PrintExportsTo(out io.Writer) {
+	redact := vars.settings().redact
+	vars.printExportsTo(out, func(vr *Var) [][2]string {
+		return vr.assignments(redact)
+	})
+}
+
+// PrintExportsToUnmasked is like PrintExportsTo, but includes the actual values
+// of Secret variables.
+func (vars VarSet) PrintExportsToUnmasked(out io.Writer) {
+	vars.printExportsTo(out, (*Var).unmaskedAssignments)
+}
+
+func (vars VarSet) printExportsTo(out io.Writer, assignments func(vr *Var) [][2]string) {
+	for _, vr := range vars {
+		if vr.isMarker() || vr.hidden {
+			continue
+		}
+		for _, a := range assignments(vr) {
+			fmt.Fprintf(out, "export %s=%s\n", a[0], shellQuote(a[1]))
+		}
+	}
+}
+
+// LogTo writes the current values of all variables to w, one KEY=value line
+// each after prefix, e.g. to record the effective configuration in the logs
+// right after Parse. Values with spaces, quotes or control characters are quoted
+// Go-style, so that every variable stays on one line. Values of Secret variables
+// are masked, and Hidden variables are omitted; use LogToUnmasked to include secrets.
+func (vars VarSet) LogTo(w io.Writer, prefix string) {
+	redact := vars.settings().redact
+	vars.logTo(w, prefix, func(vr *Var) [][2]string {
+		return vr.assignments(redact)
+	})
+}
+
+// LogToUnmasked is like LogTo, but includes the actual values of Secret variables.
+func (vars VarSet) LogToUnmasked(w io.Writer, prefix string) {
+	vars.logTo(w, prefix, (*Var).unmaskedAssignments)
+}
+
+func (vars VarSet) logTo(w io.Writer, prefix string, assignments func(vr *Var) [][2]string) {
+	if prefix != "" {
+		prefix += " "
+	}
+	for _, vr := range vars {
+		if vr.isMarker() || vr.hidden {
+			continue
+		}
+		for _, a := range assignments(vr) {
+			v := a[1]
+			if strings.ContainsAny(v, " \t\r\n\"'\\") || !strconv.CanBackquote(v) {
+				v = strconv.Quote(v)
+			}
+			fmt.Fprintf(w, "%s%s=%s\n", prefix, a[0], v)
+		}
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// PrintSystemdEnvFileTo prints the current values of all variables in the format
+// of systemd's EnvironmentFile=, with descriptions as comments. Values that are
+// not plain words are double-quoted, escaping backslashes, quotes, and characters
+// that systemd would treat specially, so they are read back verbatim, including
+// newlines; systemd does not expand variables in these files. Hidden variables
+// are omitted. Values of Secret variables are masked as ***, so a unit using
+// the output gets *** as their values; use PrintSystemdEnvFileToUnmasked
+// to generate a file for deployment.
+func (vars VarSet) PrintSystemdEnvFileTo(out io.Writer) {
+	redact := vars.settings().redact
+	vars.printSystemdEnvFileTo(out, func(vr *Var) [][2]string {
+		return vr.assignments(redact)
+	})
+}
+
+// PrintSystemdEnvFileToUnmasked is like PrintSystemdEnvFileTo, but includes
+// the actual values of Secret variables.
+func (vars VarSet) PrintSystemdEnvFileToUnmasked(out io.Writer) {
+	vars.printSystemdEnvFileTo(out, (*Var).unmaskedAssignments)
+}
+
+func (vars VarSet) printSystemdEnvFileTo(out io.Writer, assignments func(vr *Var) [][2]string) {
+	for _, vr := range vars {
+		if vr.isMarker() || vr.hidden {
+			continue
+		}
+		if vr.Desc != "" {
+			fmt.Fprint(out, "# "+strings.ReplaceAll(vr.Desc, "\n", "\n# ")+"\n")
+		}
+		for _, a := range assignments(vr) {
+			fmt.Fprintf(out, "%s=%s\n", a[0], systemdQuote(a[1]))
+		}
+	}
+}
+
+func systemdQuote(s string) string {
+	plain := s != ""
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.,:/@%+=", c)) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '\\', '"', '$', '`':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+type jsonError struct {
+	Invalid     []jsonInvalidValue `json:"invalid"`
+	Missing     []string           `json:"missing"`
+	Constraints []string           `json:"constraints,omitempty"`
+	Unknown     []string           `json:"unknown,omitempty"`
+	Hooks       []string           `json:"hooks,omitempty"`
+	Sources     []string           `json:"sources,omitempty"`
+}
+
+type jsonInvalidValue struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// PrintErrorJSON prints the given error returned by TryParse as a JSON object
+// for programmatic consumption:
+//
+//	{"invalid": [{"key": "PORT", "error": "..."}], "missing": ["DB_HOST"]}
+//
+// Values of Secret variables are masked in error messages.
+func PrintErrorJSON(e *Error, w io.Writer) error {
+	j := jsonError{
+		Invalid: []jsonInvalidValue{},
+		Missing: []string{},
+	}
+	for _, iv := range e.InvalidValues {
+		j.Invalid = append(j.Invalid, jsonInvalidValue{iv.EnvKey, iv.causeString()})
+	}
+	for _, vr := range e.MissingVars {
+		j.Missing = append(j.Missing, vr.EnvKey)
+	}
+	for _, err := range e.ConstraintErrors {
+		j.Constraints = append(j.Constraints, err.Error())
+	}
+	j.Unknown = e.UnknownKeys
+	for _, err := range e.HookErrors {
+		j.Hooks = append(j.Hooks, err.Error())
+	}
+	for _, err := range e.SourceErrors {
+		j.Sources = append(j.Sources, err.Error())
+	}
+	return json.NewEncoder(w).Encode(j)
+}
+
+// Diagnose parses the current environment like TryParse and, when that fails,
+// explains how to fix each problem on w: for every missing or invalid variable,
+// it prints the description, the expected type and a ready-to-copy export line
+// suggesting the Example or, for non-Secret variables, the default value.
+// Returns whether the environment is valid.
+func (vars VarSet) Diagnose(w io.Writer) bool {
+	e := vars.TryParse()
+	if e == nil {
+		return true
+	}
+	b := e.bullet()
+	for _, err := range e.SourceErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	for _, iv := range e.InvalidValues {
+		fmt.Fprintf(w, "%s%s: invalid value: %s\n", b, iv.EnvKey, iv.causeString())
+		if vr := vars.lookup(iv.EnvKey); vr != nil {
+			vr.printRemedy(w)
+		}
+	}
+	for _, vr := range e.MissingVars {
+		if reason := e.MissingReasons[vr.EnvKey]; reason != "" {
+			fmt.Fprintf(w, "%s%s: missing value, required because %s\n", b, vr.EnvKey, reason)
+		} else {
+			fmt.Fprintf(w, "%s%s: missing value\n", b, vr.EnvKey)
+		}
+		vr.printRemedy(w)
+	}
+	for _, err := range e.ConstraintErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	for _, key := range e.UnknownKeys {
+		fmt.Fprintf(w, "%sunknown environment variable %s\n", b, key)
+	}
+	for _, err := range e.HookErrors {
+		fmt.Fprintf(w, "%s%v\n", b, err)
+	}
+	return false
+}
+
+func (vr *Var) printRemedy(w io.Writer) {
+	if vr.Desc != "" {
+		fmt.Fprint(w, "   # "+strings.ReplaceAll(vr.Desc, "\n", "\n   # ")+"\n")
+	}
+	fmt.Fprintf(w, "   # type: %s\n", typeName(vr.Value))
+	if vr.hint != "" {
+		fmt.Fprintf(w, "   # %s\n", vr.hint)
+	}
+	suggestion := vr.example
+	if suggestion == "" && !vr.secret {
+		suggestion = vr.def
+	}
+	if suggestion == "" {
+		fmt.Fprintf(w, "   export %s=...\n", vr.EnvKey)
+	} else {
+		fmt.Fprintf(w, "   export %s=%s\n", vr.EnvKey, shellQuote(suggestion))
+	}
+}
@@ -0,0 +1,59 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func printTestVars() VarSet {
+	var vars VarSet
+	vars.Var("HOST", Optional, NewString("db.local"), "database host\nor IP")
+	vars.Var("GREETING", Optional, NewString("it's $HOME"), "")
+	vars.Var("PASSWORD", Optional, NewString("s3cret"), "").Secret()
+	vars.Var("INTERNAL", Optional, NewString("x"), "").Hidden()
+	vars.IndexedStrings("UPSTREAM_", &[]string{"a", "b"})
+	return vars
+}
+
+func TestPrintExportsTo(t *testing.T) {
+	vars := printTestVars()
+	tests := []struct {
+		name  string
+		print func(b *strings.Builder)
+		want  string
+	}{
+		{"masked", func(b *strings.Builder) { vars.PrintExportsTo(b) },
+			"export HOST='db.local'\nexport GREETING='it'\\''s $HOME'\nexport PASSWORD='***'\nexport UPSTREAM_0='a'\nexport UPSTREAM_1='b'\n"},
+		{"unmasked", func(b *strings.Builder) { vars.PrintExportsToUnmasked(b) },
+			"export HOST='db.local'\nexport GREETING='it'\\''s $HOME'\nexport PASSWORD='s3cret'\nexport UPSTREAM_0='a'\nexport UPSTREAM_1='b'\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		tt.print(&b)
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: printed:\n%s\nwanted:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrintSystemdEnvFileTo(t *testing.T) {
+	vars := printTestVars()
+	vars.Var("MULTILINE", Optional, NewString("a \"b\"\n`c` \\d"), "")
+	tests := []struct {
+		name  string
+		print func(b *strings.Builder)
+		want  string
+	}{
+		{"masked", func(b *strings.Builder) { vars.PrintSystemdEnvFileTo(b) },
+			"# database host\n# or IP\nHOST=db.local\nGREETING=\"it's \\$HOME\"\nPASSWORD=\"***\"\nUPSTREAM_0=a\nUPSTREAM_1=b\nMULTILINE=\"a \\\"b\\\"\n\\`c\\` \\\\d\"\n"},
+		{"unmasked", func(b *strings.Builder) { vars.PrintSystemdEnvFileToUnmasked(b) },
+			"# database host\n# or IP\nHOST=db.local\nGREETING=\"it's \\$HOME\"\nPASSWORD=s3cret\nUPSTREAM_0=a\nUPSTREAM_1=b\nMULTILINE=\"a \\\"b\\\"\n\\`c\\` \\\\d\"\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		tt.print(&b)
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: printed:\n%s\nwanted:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
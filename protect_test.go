@@ -0,0 +1,68 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+type panickingValue struct{}
+
+func (panickingValue) String() string   { return "" }
+func (panickingValue) Set(string) error { panic("boom") }
+
+func TestPanicsBecomeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		declare func(vars *VarSet)
+		invalid bool // reported as an invalid value of X rather than a general error
+		want    string
+	}{
+		{"Required func", func(vars *VarSet) {
+			vars.Var("X", func() bool { panic("boom") }, NewString(""), "")
+		}, false, "Required func of X panicked: boom"},
+		{"constraint", func(vars *VarSet) {
+			vars.Constrain(func() error { panic("boom") })
+		}, false, "constraint panicked: boom"},
+		{"transformation", func(vars *VarSet) {
+			vars.Var("X", Optional, NewString(""), "").Transform(func(string) (string, error) { panic("boom") })
+		}, true, "transformation panicked: boom"},
+		{"Set", func(vars *VarSet) {
+			vars.Var("X", Optional, panickingValue{}, "")
+		}, true, "Set panicked: boom"},
+		{"hook", func(vars *VarSet) {
+			vars.AfterParse(func() error { panic("boom") })
+		}, false, "AfterParse hook panicked: boom"},
+		{"OnParse", func(vars *VarSet) {
+			vars.Var("Y", Optional, NewString(""), "")
+			vars.OnParse(func(ParseEvent) { panic("boom") })
+		}, false, "OnParse callback panicked: boom"},
+		{"OnProgress", func(vars *VarSet) {
+			vars.Var("Y", Optional, NewString(""), "")
+			vars.OnProgress(func(ProgressEvent) { panic("boom") })
+		}, false, "OnProgress callback panicked: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vars VarSet
+			tt.declare(&vars)
+			e := vars.TryParseFrom(func(key string) string {
+				if tt.invalid {
+					return "value"
+				}
+				return ""
+			})
+			if e == nil {
+				t.Fatal("got no error")
+			}
+			if !strings.Contains(e.Error(), tt.want) {
+				t.Errorf("got %q, wanted %q", e.Error(), tt.want)
+			}
+			if !tt.invalid && len(e.HookErrors)+len(e.ConstraintErrors) != 1 {
+				t.Errorf("got %d hook and %d constraint errors, wanted one", len(e.HookErrors), len(e.ConstraintErrors))
+			}
+			if tt.invalid && (len(e.InvalidValues) != 1 || e.InvalidValues[0].EnvKey != "X") {
+				t.Errorf("got invalid values %v, wanted X", e.InvalidValues)
+			}
+		})
+	}
+}
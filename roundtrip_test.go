@@ -0,0 +1,147 @@
+package envloader
+
+import (
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type roundTripCase struct {
+	name  string
+	value func() flag.Value
+	raw   string
+	lossy bool // String cannot be fed back into Set
+}
+
+func roundTripCases(t *testing.T) []roundTripCase {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(file, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const cert = "-----BEGIN CERTIFICATE-----\naGVsbG8=\n-----END CERTIFICATE-----"
+
+	return []roundTripCase{
+		{"String", func() flag.Value { return StringVar(new(string)) }, "hello", false},
+		{"MaskedString", func() flag.Value { return MaskedStringVar(new(string)) }, "sk_live_0123456789", true},
+		{"NonEmptyString", func() flag.Value { return NonEmptyStringVar(new(string)) }, "x", false},
+		{"UUID", func() flag.Value { return UUIDVar(new(string)) }, "550E8400-E29B-41D4-A716-446655440000", false},
+		{"LuhnNumber", func() flag.Value { return LuhnNumberVar(new(string)) }, "4111 1111 1111 1111", false},
+		{"Pattern", func() flag.Value { return PatternVar(new(string), regexp.MustCompile(`^[a-z]+$`)) }, "abc", false},
+		{"Mapped", func() flag.Value { return MappedVar(new(string), map[string]string{"plain": "text", "text": "text"}) }, "PLAIN", false},
+		{"FileContents", func() flag.Value { return FileContentsVar(new(string)) }, file, true},
+		{"FilePath", func() flag.Value { return FilePathVar(new(string)) }, file, false},
+		{"DirPath", func() flag.Value { return DirPathVar(new(string)) }, dir, false},
+		{"Base64", func() flag.Value { return Base64Var(new([]byte), nil) }, "aGVsbG8", false},
+		{"Duration", func() flag.Value { return DurationVar(new(time.Duration)) }, "1h30m", false},
+		{"NonNegativeDuration", func() flag.Value { return NonNegativeDurationVar(new(time.Duration)) }, "90s", false},
+		{"ISODuration", func() flag.Value { return ISODurationVar(new(time.Duration)) }, "PT1H30M", false},
+		{"Int", func() flag.Value { return IntVar(new(int)) }, "-42", false},
+		{"IntBase", func() flag.Value { return IntVarBase(new(int), 16) }, "ff", false},
+		{"IntBaseAuto", func() flag.Value { return IntVarBase(new(int), 0) }, "0x1f", false},
+		{"Int64", func() flag.Value { return Int64Var(new(int64)) }, "-9000000000", false},
+		{"Uint64", func() flag.Value { return Uint64Var(new(uint64)) }, "18000000000000000000", false},
+		{"Float64", func() flag.Value { return Float64Var(new(float64)) }, "2.5e-3", false},
+		{"BoundedInt", func() flag.Value { return BoundedIntVar(new(int), 1, 10) }, "7", false},
+		{"Uint", func() flag.Value { return UintVar(new(uint)) }, "42", false},
+		{"FileMode", func() flag.Value { return FileModeVar(new(os.FileMode)) }, "0640", false},
+		{"SlogLevel", func() flag.Value { return SlogLevelVar(new(slog.Level)) }, "warn", false},
+		{"Percent", func() flag.Value { return PercentVar(new(float64)) }, "12.5%", false},
+		{"PercentRange", func() flag.Value { return PercentVar(new(float64)).Range(0, 2) }, "150%", false},
+		{"RolloutPercent", func() flag.Value { return RolloutPercentVar(new(int)) }, "25", false},
+		{"Ratio", func() flag.Value { return RatioVar(new(float64)) }, "1/4", false},
+		{"Bool", func() flag.Value { return BoolVar(new(bool)) }, "true", false},
+		{"BoolWith", func() flag.Value { return BoolVarWith(new(bool), []string{"si"}, []string{"no"}) }, "si", false},
+		{"FlagBool", func() flag.Value { return FlagBoolVar(new(bool)) }, "true", false},
+		{"OnOffBool", func() flag.Value { return OnOffBoolVar(new(bool)) }, "on", false},
+		{"InvertedBool", func() flag.Value { return InvertedBoolVar(new(bool)) }, "false", false},
+		{"NullBool", func() flag.Value { return NullBoolVar(new(*bool)) }, "false", false},
+		{"BoolOrDuration", func() flag.Value { return BoolOrDurationVar(new(Toggle)) }, "30m", false},
+		{"ByteSize", func() flag.Value { return ByteSizeVar(new(int64)) }, "1.5GB", false},
+		{"Rate", func() flag.Value { return RateVar(new(int64)) }, "10MB/s", false},
+		{"SIQuantity", func() flag.Value { return SIQuantityVar(new(int64)) }, "2.5k", false},
+		{"SemVer", func() flag.Value { return SemVerVar(new(Version)) }, "v1.2.3-rc.1+build.5", false},
+		{"Cron", func() flag.Value { return CronVar(new(string)) }, "*/15 2 * * mon-fri", false},
+		{"GitRef", func() flag.Value { return GitRefVar(new(string)) }, "release/v1.2", false},
+		{"DNSLabel", func() flag.Value { return DNSLabelVar(new(string)) }, "api-1", false},
+		{"DNSSubdomain", func() flag.Value { return DNSSubdomainVar(new(string)) }, "api.example.com", false},
+		{"DSN", func() flag.Value { return DSNVar(new(string)) }, "postgres://user:pass@db:5432/app?sslmode=require", false},
+		{"Email", func() flag.Value { return EmailVar(new(string)) }, "ops@example.com", false},
+		{"HostPort", func() flag.Value { return HostPortVar(new(string)) }, "example.com:8080", false},
+		{"HostPortList", func() flag.Value { return HostPortListVar(new([]string), 6379) }, "a:1, b", false},
+		{"PEM", func() flag.Value { return PEMVar(new([]byte)) }, cert, true},
+		{"StringSlice", func() flag.Value { return StringSliceVar(new([]string)) }, "a, b,c", false},
+		{"SortedStringSlice", func() flag.Value { return SortedStringSliceVar(new([]string)) }, "c,a,b", false},
+		{"StringSet", func() flag.Value { return StringSetVar(new(StringSet)) }, "b,a,b", false},
+		{"EnumSet", func() flag.Value { return EnumSetVar(new([]string), "read", "write") }, "write,read", false},
+		{"IntSlice", func() flag.Value { return IntSliceVar(new([]int)) }, "3,1,2", false},
+		{"Float64Slice", func() flag.Value { return Float64SliceVar(new([]float64)) }, "0.5,2", false},
+		{"DurationSlice", func() flag.Value { return DurationSliceVar(new([]time.Duration)) }, "1s,2m", false},
+		{"Fields", func() flag.Value { return FieldsVar(new([]string)) }, "a  b c", false},
+		{"ListOrFile", func() flag.Value { return ListOrFileVar(new([]string)) }, "x,y", false},
+		{"WeightedList", func() flag.Value { return WeightedListVar(new([]Weighted)) }, "a:3,b", false},
+		{"EnumTable", func() flag.Value { return EnumTableVar(new(int), map[string]int{"low": 1, "high": 2}) }, "high", false},
+		{"JSON", func() flag.Value { return JSONVar(new(map[string]int)) }, `{"a": 1}`, false},
+		{"JSONSchema", func() flag.Value {
+			return JSONSchemaVar(new(json.RawMessage), JSONValidatorFunc(func([]byte) error { return nil }))
+		}, `{"a":1}`, false},
+	}
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	for _, c := range roundTripCases(t) {
+		if c.lossy {
+			continue
+		}
+		a := c.value()
+		if err := a.Set(c.raw); err != nil {
+			t.Errorf("%s: Set(%q): %v", c.name, c.raw, err)
+			continue
+		}
+		s := a.String()
+		b := c.value()
+		if err := b.Set(s); err != nil {
+			t.Errorf("%s: Set(%q) of String: %v", c.name, s, err)
+			continue
+		}
+		if got := b.String(); got != s {
+			t.Errorf("%s: String after round trip = %q, wanted %q", c.name, got, s)
+		}
+		if fa, fb := fingerprint(a), fingerprint(b); fa != fb {
+			t.Errorf("%s: value after round trip = %s, wanted %s", c.name, fb, fa)
+		}
+	}
+}
+
+func TestCaptureRestoreAllTypes(t *testing.T) {
+	for _, c := range roundTripCases(t) {
+		var vars VarSet
+		vars.Var("X", Optional, c.value(), "")
+		empty := vars.Capture()
+		emptyFP := fingerprint(vars[0].Value)
+
+		if err := vars.TryParseFrom(func(string) string { return c.raw }); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		parsed := vars.Capture()
+		parsedFP := fingerprint(vars[0].Value)
+		if parsedFP == emptyFP {
+			t.Errorf("%s: parsing did not change the value", c.name)
+		}
+
+		vars.Restore(empty)
+		if got := fingerprint(vars[0].Value); got != emptyFP || vars[0].IsSpecified {
+			t.Errorf("%s: after restoring the empty state: %s specified=%v, wanted %s", c.name, got, vars[0].IsSpecified, emptyFP)
+		}
+		vars.Restore(parsed)
+		if got := fingerprint(vars[0].Value); got != parsedFP || !vars[0].IsSpecified {
+			t.Errorf("%s: after restoring the parsed state: %s specified=%v, wanted %s", c.name, got, vars[0].IsSpecified, parsedFP)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package envloader
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TypeNamer is implemented by values that can name their type for schemas
+// and documentation, like "int", "duration" or "[]string".
+// All value types defined by this package implement TypeNamer.
+type TypeNamer interface {
+	TypeName() string
+}
+
+// typeName returns the TypeName of v, falling back to the lower-cased name
+// of its Go type for values defined elsewhere, like a *logx.Level becoming "level".
+func typeName(v flag.Value) string {
+	if tn, ok := v.(TypeNamer); ok {
+		return tn.TypeName()
+	}
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "string"
+	}
+	return strings.ToLower(t.Name())
+}
+
+// Requiredness of variables reported by Describe.
+const (
+	RequirednessRequired    = "required"
+	RequirednessOptional    = "optional"
+	RequirednessConditional = "conditional" // depends on other variables or runtime state
+)
+
+// VarInfo describes the definition of a variable, without its current value.
+type VarInfo struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Required string `json:"required"`
+	Desc     string `json:"desc,omitempty"`
+	Default  string `json:"default,omitempty"`
+	Example  string `json:"example,omitempty"`
+	Section  string `json:"section,omitempty"`
+	Secret   bool   `json:"secret,omitempty"`
+}
+
+// Describe returns the definitions of all variables in the set, in order.
+// Defaults of Secret variables are masked. Hidden variables are omitted.
+func (vars VarSet) Describe() []VarInfo {
+	var result []VarInfo
+	var section string
+	for _, vr := range vars {
+		if vr.section != "" {
+			section = vr.section
+		}
+		if vr.isMarker() || vr.hidden {
+			continue
+		}
+		def := vr.def
+		if vr.secret && def != "" {
+			def = masked
+		}
+		result = append(result, VarInfo{
+			Key:      vr.EnvKey,
+			Type:     typeName(vr.Value),
+			Required: vr.requiredness(),
+			Desc:     vr.Desc,
+			Default:  def,
+			Example:  vr.example,
+			Section:  section,
+			Secret:   vr.secret,
+		})
+	}
+	return result
+}
+
+func (vr *Var) requiredness() string {
+	if vr.Required == nil {
+		return RequirednessOptional
+	}
+	switch reflect.ValueOf(vr.Required).Pointer() {
+	case reflect.ValueOf(Required).Pointer():
+		return RequirednessRequired
+	case reflect.ValueOf(Optional).Pointer():
+		return RequirednessOptional
+	default:
+		return RequirednessConditional
+	}
+}
+
+// WriteSchema writes the definitions of all variables, as returned by Describe,
+// as a JSON document for use by other tools:
+//
+//	{"vars": [{"key": "PORT", "type": "int", "required": "optional", "default": "8080"}]}
+func (vars VarSet) WriteSchema(w io.Writer) error {
+	schema := Schema{vars.Describe()}
+	if schema.Vars == nil {
+		schema.Vars = []VarInfo{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// Schema is a set of variable definitions detached from any bound Go variables,
+// e.g. read from the output of WriteSchema. It allows checking configuration
+// of other programs, like a linter of .env files.
+type Schema struct {
+	Vars []VarInfo `json:"vars"`
+}
+
+// ReadSchema reads a schema in the format written by WriteSchema.
+func ReadSchema(r io.Reader) (*Schema, error) {
+	var schema Schema
+	if err := json.NewDecoder(r).Decode(&schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// Validate parses env against the schema, reporting missing and invalid
+// variables like TryParseMap. Only the Key, Type and Required fields are used.
+// Values of the types that need configuration, like enums, and of unknown types
+// are only checked for presence. Conditionally required variables are treated
+// as optional, because their conditions are not part of the schema.
+func (schema *Schema) Validate(env map[string]string) *Error {
+	var vars VarSet
+	for _, info := range schema.Vars {
+		required := Optional
+		if info.Required == RequirednessRequired {
+			required = Required
+		}
+		vars.Var(info.Key, required, schemaValue(info.Type), info.Desc)
+	}
+	return vars.TryParseMap(env)
+}
+
+// schemaValue returns a value that parses the given type name for Schema.Validate,
+// falling back to a string for unknown types.
+func schemaValue(typ string) flag.Value {
+	var base int
+	if n, _ := fmt.Sscanf(typ, "int(base%d)", &base); n == 1 {
+		return IntVarBase(new(int), base)
+	}
+	if newValue := schemaTypes[typ]; newValue != nil {
+		return newValue()
+	}
+	return new(String)
+}
+
+// schemaTypes creates values for the type names used by Schema.Validate.
+// Ranges are not part of the schema, so bounded values like percentages
+// accept any value of their type.
+var schemaTypes = map[string]func() flag.Value{
+	"string":        func() flag.Value { return new(String) },
+	"int":           func() flag.Value { return new(Int) },
+	"int64":         func() flag.Value { return new(Int64) },
+	"uint":          func() flag.Value { return new(Uint) },
+	"uint64":        func() flag.Value { return new(Uint64) },
+	"float64":       func() flag.Value { return new(Float64) },
+	"bool":          func() flag.Value { return new(Bool) },
+	"flagbool":      func() flag.Value { return new(FlagBool) },
+	"nullbool":      func() flag.Value { return NullBoolVar(new(*bool)) },
+	"duration":      func() flag.Value { return new(Duration) },
+	"isoduration":   func() flag.Value { return ISODurationVar(new(time.Duration)) },
+	"bool|duration": func() flag.Value { return new(BoolOrDuration) },
+	"[]string":      func() flag.Value { return new(StringSlice) },
+	"[]int":         func() flag.Value { return new(IntSlice) },
+	"[]float64":     func() flag.Value { return new(Float64Slice) },
+	"[]duration":    func() flag.Value { return new(DurationSlice) },
+	"bytesize":      func() flag.Value { return new(ByteSize) },
+	"rate":          func() flag.Value { return new(Rate) },
+	"quantity":      func() flag.Value { return new(SIQuantity) },
+	"percent":       func() flag.Value { return PercentVar(new(float64)).Range(math.Inf(-1), math.Inf(1)) },
+	"ratio":         func() flag.Value { return new(Ratio) },
+	"rollout":       func() flag.Value { return new(RolloutPercent) },
+	"filemode":      func() flag.Value { return new(FileMode) },
+	"loglevel":      func() flag.Value { return new(SlogLevel) },
+	"uuid":          func() flag.Value { return new(UUID) },
+	"luhn":          func() flag.Value { return new(LuhnNumber) },
+	"email":         func() flag.Value { return new(Email) },
+	"host:port":     func() flag.Value { return new(HostPort) },
+	"dsn":           func() flag.Value { return new(DSN) },
+	"semver":        func() flag.Value { return new(SemVer) },
+	"gitref":        func() flag.Value { return new(GitRef) },
+	"dnslabel":      func() flag.Value { return new(DNSLabel) },
+	"dnssubdomain":  func() flag.Value { return new(DNSSubdomain) },
+	"cron":          func() flag.Value { return CronVar(new(string)) },
+}
+
+// WriteMarkdownTable writes the definitions of all variables, as returned
+// by Describe, as a Markdown table with Variable, Required, Default,
+// Description and Example columns, e.g. for a configuration reference.
+func (vars VarSet) WriteMarkdownTable(w io.Writer) error {
+	var buf strings.Builder
+	buf.WriteString("| Variable | Required | Default | Description | Example |\n")
+	buf.WriteString("|---|---|---|---|---|\n")
+	for _, info := range vars.Describe() {
+		fmt.Fprintf(&buf, "| `%s` | %s | %s | %s | %s |\n",
+			info.Key, info.Required, markdownCode(info.Default), markdownCell(info.Desc), markdownCode(info.Example))
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
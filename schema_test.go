@@ -0,0 +1,116 @@
+package envloader
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// logLevel stands for a flag.Value from another library: no Getter, Cloner or TypeNamer.
+type logLevel int
+
+func (l *logLevel) String() string {
+	if l == nil {
+		return ""
+	}
+	return [...]string{"info", "debug"}[*l]
+}
+
+func (l *logLevel) Set(raw string) error {
+	switch raw {
+	case "info":
+		*l = 0
+	case "debug":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", raw)
+	}
+	return nil
+}
+
+func TestForeignValue(t *testing.T) {
+	var level logLevel
+	var vars VarSet
+	vars.Var("LOG_LEVEL", Optional, &level, "log level")
+
+	if err := vars.TryParseFrom(func(string) string { return "debug" }); err != nil {
+		t.Fatal(err)
+	}
+	if level != 1 {
+		t.Errorf("level = %d", level)
+	}
+	if got := vars[0].Interface(); got != "debug" {
+		t.Errorf("Interface() = %#v, wanted the String", got)
+	}
+
+	info := vars.Describe()
+	if info[0].Type != "loglevel" || info[0].Default != "info" {
+		t.Errorf("Describe() = %+v, wanted the Go type name and the default", info[0])
+	}
+	if _, err := json.Marshal(info); err != nil {
+		t.Error(err)
+	}
+	if got := vars.AsMap()["LOG_LEVEL"]; got != "debug" {
+		t.Errorf("AsMap() = %q", got)
+	}
+	var out strings.Builder
+	vars.PrintTo(&out)
+	if !strings.Contains(out.String(), "LOG_LEVEL") || !strings.Contains(out.String(), "debug") {
+		t.Errorf("PrintTo printed %q", out.String())
+	}
+
+	e := vars.TryParseFrom(func(string) string { return "trace" })
+	if e == nil || len(e.InvalidValues) != 1 || !strings.Contains(e.Error(), `unknown level "trace"`) {
+		t.Errorf("got %v, wanted an invalid LOG_LEVEL", e)
+	}
+}
+
+func TestSchemaAcceptsWhatValuesAccept(t *testing.T) {
+	for _, c := range roundTripCases(t) {
+		var vars VarSet
+		vars.Var("X", Required, c.value(), "")
+		var buf strings.Builder
+		if err := vars.WriteSchema(&buf); err != nil {
+			t.Fatal(err)
+		}
+		schema, err := ReadSchema(strings.NewReader(buf.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := schema.Validate(map[string]string{"X": c.raw}); e != nil {
+			t.Errorf("%s (%s): %q rejected: %v", c.name, schema.Vars[0].Type, c.raw, e)
+		}
+	}
+}
+
+func TestSchemaTypeNames(t *testing.T) {
+	tests := []struct {
+		value    flag.Value
+		typ      string
+		accepted string
+		rejected string
+	}{
+		{IntVarBase(new(int), 16), "int(base16)", "ff", "fg"},
+		{IntVarBase(new(int), 0), "int(base0)", "0x1f", "0x1g"},
+		{IntVarBase(new(int), 10), "int", "42", "ff"},
+		{RatioVar(new(float64)), "ratio", "1/100", "1/0"},
+		{FlagBoolVar(new(bool)), "flagbool", "", "maybe"},
+		{NullBoolVar(new(*bool)), "nullbool", "", "maybe"},
+	}
+	for _, tt := range tests {
+		var vars VarSet
+		vars.Var("X", Required, tt.value, "")
+		schema := &Schema{vars.Describe()}
+		if got := schema.Vars[0].Type; got != tt.typ {
+			t.Errorf("%T: type = %q, wanted %q", tt.value, got, tt.typ)
+		}
+		if e := schema.Validate(map[string]string{"X": tt.accepted}); e != nil {
+			t.Errorf("%s: %q rejected: %v", tt.typ, tt.accepted, e)
+		}
+		if e := schema.Validate(map[string]string{"X": tt.rejected}); e == nil {
+			t.Errorf("%s: %q accepted", tt.typ, tt.rejected)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version like 1.2.3-rc.1+build.5, as returned by SemVer.Get.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string // pre-release identifiers, like rc.1
+	Build               string // build metadata, ignored by Compare
+}
+
+func (ver Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", ver.Major, ver.Minor, ver.Patch)
+	if ver.Pre != "" {
+		s += "-" + ver.Pre
+	}
+	if ver.Build != "" {
+		s += "+" + ver.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether ver precedes, equals or
+// follows other according to the semantic versioning precedence rules.
+func (ver Version) Compare(other Version) int {
+	if c := compareInts(ver.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(ver.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(ver.Patch, other.Patch); c != 0 {
+		return c
+	}
+	switch {
+	case ver.Pre == other.Pre:
+		return 0
+	case ver.Pre == "":
+		return 1
+	case other.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(ver.Pre, "."), strings.Split(other.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, aerr := strconv.Atoi(a[i])
+		bn, berr := strconv.Atoi(b[i])
+		var c int
+		switch {
+		case aerr == nil && berr == nil:
+			c = compareInts(an, bn)
+		case aerr == nil:
+			c = -1 // numeric identifiers have lower precedence
+		case berr == nil:
+			c = 1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func parseVersion(raw string) (Version, error) {
+	var ver Version
+	s := strings.TrimPrefix(raw, "v")
+	var hasPre, hasBuild bool
+	s, ver.Build, hasBuild = strings.Cut(s, "+")
+	s, ver.Pre, hasPre = strings.Cut(s, "-")
+	if hasPre && ver.Pre == "" || hasBuild && ver.Build == "" {
+		return Version{}, fmt.Errorf("empty pre-release or build identifiers in %q", raw)
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("expected a version like 1.2.3")
+	}
+	for i, dst := range []*int{&ver.Major, &ver.Minor, &ver.Patch} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 || (len(parts[i]) > 1 && parts[i][0] == '0') {
+			return Version{}, fmt.Errorf("invalid version component %q", parts[i])
+		}
+		*dst = n
+	}
+	for _, ids := range []string{ver.Pre, ver.Build} {
+		if ids == "" {
+			continue
+		}
+		for _, id := range strings.Split(ids, ".") {
+			if id == "" || strings.Trim(id, "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-") != "" {
+				return Version{}, fmt.Errorf("invalid version identifier %q", id)
+			}
+		}
+	}
+	return ver, nil
+}
+
+// SemVerVar returns a value that parses semantic versions like 1.2.3,
+// optionally with a v prefix, pre-release identifiers and build metadata,
+// like v1.2.3-rc.1+build.5.
+func SemVerVar(v *Version) *SemVer {
+	return (*SemVer)(v)
+}
+
+type SemVer Version
+
+// String renders the version; an unset one renders as 0.0.0, like a zero IntVar
+// renders as 0, because Get returns the same Version in both cases.
+func (v SemVer) String() string {
+	return Version(v).String()
+}
+
+func (v SemVer) Get() interface{} {
+	return Version(v)
+}
+
+func (v *SemVer) Set(raw string) error {
+	ver, err := parseVersion(raw)
+	if err != nil {
+		return err
+	}
+	*v = SemVer(ver)
+	return nil
+}
+
+func (v *SemVer) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *SemVer) TypeName() string {
+	return "semver"
+}
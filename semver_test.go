@@ -0,0 +1,74 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSemVer(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Version
+		err  string
+	}{
+		{"1.2.3", Version{1, 2, 3, "", ""}, ""},
+		{"v0.10.0", Version{0, 10, 0, "", ""}, ""},
+		{"1.2.3-rc.1+build.5", Version{1, 2, 3, "rc.1", "build.5"}, ""},
+		{"1.2.3+exp.sha-5114f85", Version{1, 2, 3, "", "exp.sha-5114f85"}, ""},
+		{"1.2.3-alpha-2", Version{1, 2, 3, "alpha-2", ""}, ""},
+		{"1.2", Version{}, "expected a version like 1.2.3"},
+		{"1.2.3.4", Version{}, "expected a version like 1.2.3"},
+		{"01.2.3", Version{}, `invalid version component "01"`},
+		{"1.-2.3", Version{}, "expected a version like 1.2.3"},
+		{"1.x.3", Version{}, `invalid version component "x"`},
+		{"1.2.3-", Version{}, "empty pre-release or build identifiers"},
+		{"1.2.3+", Version{}, "empty pre-release or build identifiers"},
+		{"1.2.3-rc..1", Version{}, `invalid version identifier ""`},
+		{"1.2.3-rc_1", Version{}, `invalid version identifier "rc_1"`},
+	}
+	for _, tt := range tests {
+		var ver Version
+		err := SemVerVar(&ver).Set(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || ver != tt.want {
+			t.Errorf("%q: got %+v, %v, wanted %+v", tt.raw, ver, err, tt.want)
+		}
+	}
+}
+
+func TestSemVerString(t *testing.T) {
+	var ver Version
+	v := SemVerVar(&ver)
+	if got := v.String(); got != "0.0.0" {
+		t.Errorf("unset version renders as %q", got)
+	}
+	if err := v.Set("v1.2.3-rc.1+b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.String(); got != "1.2.3-rc.1+b" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := parseVersion(ordered[i])
+			b, _ := parseVersion(ordered[j])
+			if got, want := a.Compare(b), compareInts(i, j); got != want {
+				t.Errorf("%s vs %s = %d, wanted %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+	a, _ := parseVersion("1.0.0+a")
+	b, _ := parseVersion("1.0.0+b")
+	if a.Compare(b) != 0 {
+		t.Errorf("build metadata affects precedence")
+	}
+}
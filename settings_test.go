@@ -0,0 +1,54 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkersHaveValues(t *testing.T) {
+	var vars VarSet
+	vars.CaseInsensitive()
+	vars.Section("Server")
+	vars.Var("HOST", Optional, NewString("localhost"), "")
+	vars.RequireAtLeastOne("HOST")
+	vars.AfterParse(func() error { return nil })
+	vars.Freeze()
+
+	var values []string
+	for _, vr := range vars {
+		values = append(values, vr.Value.String())
+		if vr.EnvKey == "" && vr.Value.Set("x") == nil {
+			t.Errorf("a marker accepted a value")
+		}
+	}
+	if got := strings.Join(values, ","); got != ",,localhost,,," {
+		t.Errorf("values = %q", got)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	declare := func(vars *VarSet) (panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		vars.Var("LATE", Optional, NewString(""), "")
+		return false
+	}
+
+	var a, b VarSet
+	a.Var("A", Optional, NewString(""), "")
+	b.Var("B", Optional, NewString(""), "")
+	if declare(&a) {
+		t.Fatal("declaring in an unfrozen set panicked")
+	}
+	a.Freeze()
+	a.Freeze()
+	if !declare(&a) {
+		t.Error("declaring in a frozen set did not panic")
+	}
+	if view := a.Filter("x"); !declare(&view) {
+		t.Error("declaring in a view of a frozen set did not panic")
+	}
+	merged := Merge(a, b)
+	if !declare(&merged) {
+		t.Error("declaring in a merge of a frozen set did not panic")
+	}
+}
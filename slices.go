@@ -0,0 +1,490 @@
+package envloader
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// splitList splits a comma-separated list, trimming whitespace around elements.
+// An empty string yields an empty list.
+func splitList(raw string) []string {
+	if raw == "" {
+		return []string{}
+	}
+	items := strings.Split(raw, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
+
+func elementError(item string, err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		err = ne.Err
+	}
+	return fmt.Errorf("element %q: %w", item, err)
+}
+
+// StringSliceVar returns a value that parses a comma-separated list of strings.
+// Set replaces the slice contents; an empty list yields an empty non-nil slice.
+func StringSliceVar(v *[]string) *StringSlice {
+	return (*StringSlice)(v)
+}
+
+type StringSlice []string
+
+func (v StringSlice) String() string {
+	return strings.Join(v, ",")
+}
+
+func (v StringSlice) Get() interface{} {
+	return []string(v)
+}
+
+func (v *StringSlice) Set(raw string) error {
+	*v = splitList(raw)
+	return nil
+}
+
+func (v *StringSlice) Clone() flag.Value {
+	c := append(StringSlice(nil), *v...)
+	return &c
+}
+
+func (v *StringSlice) TypeName() string {
+	return "[]string"
+}
+
+// ListOrFileVar returns a value that parses a comma-separated list of strings
+// like StringSliceVar, or, when the raw value starts with @, reads the list from
+// the named file, one element per line, skipping blank lines and # comments:
+// ALLOWED_IPS=@/etc/myapp/allowed_ips.txt.
+func ListOrFileVar(v *[]string) *ListOrFile {
+	return (*ListOrFile)(v)
+}
+
+type ListOrFile []string
+
+func (v ListOrFile) String() string {
+	return strings.Join(v, ",")
+}
+
+func (v ListOrFile) Get() interface{} {
+	return []string(v)
+}
+
+func (v *ListOrFile) Set(raw string) error {
+	path, ok := strings.CutPrefix(raw, "@")
+	if !ok {
+		*v = splitList(raw)
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	items := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			items = append(items, line)
+		}
+	}
+	*v = items
+	return nil
+}
+
+func (v *ListOrFile) Clone() flag.Value {
+	c := append(ListOrFile(nil), *v...)
+	return &c
+}
+
+func (v *ListOrFile) TypeName() string {
+	return "[]string"
+}
+
+// Weighted is a name with a weight, as parsed by WeightedListVar.
+type Weighted struct {
+	Name   string
+	Weight int
+}
+
+// WeightedListVar returns a value that parses a comma-separated list of
+// name:weight pairs, like a:3,b:1,c, where the weight defaults to 1
+// and must not be negative.
+func WeightedListVar(v *[]Weighted) *WeightedList {
+	return (*WeightedList)(v)
+}
+
+type WeightedList []Weighted
+
+func (v WeightedList) String() string {
+	items := make([]string, len(v))
+	for i, w := range v {
+		items[i] = w.Name + ":" + strconv.Itoa(w.Weight)
+	}
+	return strings.Join(items, ",")
+}
+
+func (v WeightedList) Get() interface{} {
+	return []Weighted(v)
+}
+
+func (v *WeightedList) Set(raw string) error {
+	items := splitList(raw)
+	result := make(WeightedList, 0, len(items))
+	for _, item := range items {
+		name, weightStr, ok := strings.Cut(item, ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("element %q: missing name", item)
+		}
+		weight := 1
+		if ok {
+			var err error
+			weight, err = strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil {
+				return fmt.Errorf("element %q: invalid weight", item)
+			}
+			if weight < 0 {
+				return fmt.Errorf("element %q: weight must not be negative", item)
+			}
+		}
+		result = append(result, Weighted{name, weight})
+	}
+	*v = result
+	return nil
+}
+
+func (v *WeightedList) Clone() flag.Value {
+	c := append(WeightedList(nil), *v...)
+	return &c
+}
+
+func (v *WeightedList) TypeName() string {
+	return "[]name:weight"
+}
+
+// EnumSetVar returns a value that parses a comma-separated list of strings,
+// like GET,POST,PUT, where every element must be one of allowed.
+func EnumSetVar(v *[]string, allowed ...string) *EnumSet {
+	return &EnumSet{v, allowed}
+}
+
+type EnumSet struct {
+	v       *[]string
+	allowed []string
+}
+
+func (v *EnumSet) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return strings.Join(*v.v, ",")
+}
+
+func (v *EnumSet) Get() interface{} {
+	return *v.v
+}
+
+func (v *EnumSet) Set(raw string) error {
+	items := splitList(raw)
+outer:
+	for _, item := range items {
+		for _, a := range v.allowed {
+			if item == a {
+				continue outer
+			}
+		}
+		return fmt.Errorf("element %q: must be one of %s", item, strings.Join(v.allowed, ", "))
+	}
+	*v.v = items
+	return nil
+}
+
+func (v *EnumSet) Clone() flag.Value {
+	c := append([]string(nil), *v.v...)
+	return &EnumSet{&c, v.allowed}
+}
+
+func (v *EnumSet) TypeName() string {
+	return "[]enum"
+}
+
+// SortedStringSliceVar returns a value that parses a comma-separated list
+// of strings like StringSliceVar, but sorts it and removes duplicates and empty
+// elements, so that the value has a canonical form: b,a,b becomes a,b.
+func SortedStringSliceVar(v *[]string) *SortedStringSlice {
+	return (*SortedStringSlice)(v)
+}
+
+type SortedStringSlice []string
+
+func (v SortedStringSlice) String() string {
+	return strings.Join(v, ",")
+}
+
+func (v SortedStringSlice) Get() interface{} {
+	return []string(v)
+}
+
+func (v *SortedStringSlice) Set(raw string) error {
+	items := splitList(raw)
+	sort.Strings(items)
+	result := items[:0]
+	for i, item := range items {
+		if item != "" && (i == 0 || item != items[i-1]) {
+			result = append(result, item)
+		}
+	}
+	*v = result
+	return nil
+}
+
+func (v *SortedStringSlice) Clone() flag.Value {
+	c := append(SortedStringSlice(nil), *v...)
+	return &c
+}
+
+func (v *SortedStringSlice) TypeName() string {
+	return "[]string"
+}
+
+// StringSetVar returns a value that parses a comma-separated list of strings
+// into a set. Unlike the slice values, Set adds to the existing members,
+// e.g. the defaults. Empty elements are ignored.
+func StringSetVar(v *StringSet) *StringSet {
+	return v
+}
+
+// StringSet is a set of strings, rendered as a sorted comma-separated list.
+type StringSet map[string]struct{}
+
+// Has returns whether the set contains s.
+func (v StringSet) Has(s string) bool {
+	_, ok := v[s]
+	return ok
+}
+
+func (v StringSet) String() string {
+	items := make([]string, 0, len(v))
+	for item := range v {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+func (v StringSet) Get() interface{} {
+	return v
+}
+
+func (v *StringSet) Set(raw string) error {
+	if *v == nil {
+		*v = make(StringSet)
+	}
+	for _, item := range splitList(raw) {
+		if item != "" {
+			(*v)[item] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (v *StringSet) Clone() flag.Value {
+	c := make(StringSet, len(*v))
+	for item := range *v {
+		c[item] = struct{}{}
+	}
+	return &c
+}
+
+func (v *StringSet) TypeName() string {
+	return "set"
+}
+
+// IntSliceVar returns a value that parses a comma-separated list of ints.
+// Set replaces the slice contents; an empty list yields an empty non-nil slice.
+func IntSliceVar(v *[]int) *IntSlice {
+	return (*IntSlice)(v)
+}
+
+type IntSlice []int
+
+func (v IntSlice) String() string {
+	items := make([]string, len(v))
+	for i, n := range v {
+		items[i] = strconv.Itoa(n)
+	}
+	return strings.Join(items, ",")
+}
+
+func (v IntSlice) Get() interface{} {
+	return []int(v)
+}
+
+func (v *IntSlice) Set(raw string) error {
+	items := splitList(raw)
+	result := make([]int, 0, len(items))
+	for _, item := range items {
+		n, err := strconv.ParseInt(item, 10, 0)
+		if err != nil {
+			return elementError(item, intError(item, err, "int"))
+		}
+		result = append(result, int(n))
+	}
+	*v = result
+	return nil
+}
+
+func (v *IntSlice) Clone() flag.Value {
+	c := append(IntSlice(nil), *v...)
+	return &c
+}
+
+func (v *IntSlice) TypeName() string {
+	return "[]int"
+}
+
+// Float64SliceVar returns a value that parses a comma-separated list of floats.
+// Set replaces the slice contents; an empty list yields an empty non-nil slice.
+func Float64SliceVar(v *[]float64) *Float64Slice {
+	return (*Float64Slice)(v)
+}
+
+type Float64Slice []float64
+
+func (v Float64Slice) String() string {
+	items := make([]string, len(v))
+	for i, f := range v {
+		items[i] = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return strings.Join(items, ",")
+}
+
+func (v Float64Slice) Get() interface{} {
+	return []float64(v)
+}
+
+func (v *Float64Slice) Set(raw string) error {
+	items := splitList(raw)
+	result := make([]float64, 0, len(items))
+	for _, item := range items {
+		f, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return elementError(item, err)
+		}
+		result = append(result, f)
+	}
+	*v = result
+	return nil
+}
+
+func (v *Float64Slice) Clone() flag.Value {
+	c := append(Float64Slice(nil), *v...)
+	return &c
+}
+
+func (v *Float64Slice) TypeName() string {
+	return "[]float64"
+}
+
+// DurationSliceVar returns a value that parses a comma-separated list of durations,
+// like 1s,2s,5s. Set replaces the slice contents; an empty list yields an empty
+// non-nil slice.
+func DurationSliceVar(v *[]time.Duration) *DurationSlice {
+	return (*DurationSlice)(v)
+}
+
+type DurationSlice []time.Duration
+
+func (v DurationSlice) String() string {
+	items := make([]string, len(v))
+	for i, d := range v {
+		items[i] = d.String()
+	}
+	return strings.Join(items, ",")
+}
+
+func (v DurationSlice) Get() interface{} {
+	return []time.Duration(v)
+}
+
+func (v *DurationSlice) Set(raw string) error {
+	items := splitList(raw)
+	result := make([]time.Duration, 0, len(items))
+	for _, item := range items {
+		d, err := time.ParseDuration(item)
+		if err != nil {
+			return elementError(item, err)
+		}
+		result = append(result, d)
+	}
+	*v = result
+	return nil
+}
+
+func (v *DurationSlice) Clone() flag.Value {
+	c := append(DurationSlice(nil), *v...)
+	return &c
+}
+
+func (v *DurationSlice) TypeName() string {
+	return "[]duration"
+}
+
+// FieldsVar returns a value that parses a list separated by any mix of commas
+// and whitespace, like "a,b c, d", skipping empty elements. String joins
+// the elements with a single space; use Separator to change that.
+func FieldsVar(v *[]string) *Fields {
+	return &Fields{v, " "}
+}
+
+type Fields struct {
+	v   *[]string
+	sep string
+}
+
+// Separator sets the string that String puts between the elements.
+func (v *Fields) Separator(sep string) *Fields {
+	v.sep = sep
+	return v
+}
+
+func (v *Fields) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return strings.Join(*v.v, v.sep)
+}
+
+func (v *Fields) Get() interface{} {
+	return *v.v
+}
+
+func (v *Fields) Set(raw string) error {
+	*v.v = strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if *v.v == nil {
+		*v.v = []string{}
+	}
+	return nil
+}
+
+func (v *Fields) Clone() flag.Value {
+	c := append([]string(nil), *v.v...)
+	return &Fields{&c, v.sep}
+}
+
+func (v *Fields) TypeName() string {
+	return "[]string"
+}
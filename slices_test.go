@@ -0,0 +1,14 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDurationSliceElementError(t *testing.T) {
+	var ds DurationSlice
+	err := ds.Set("1s, 5x, 2m")
+	if err == nil || !strings.HasPrefix(err.Error(), `element "5x": `) {
+		t.Errorf("got %v, wanted an error about element 5x", err)
+	}
+}
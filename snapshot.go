@@ -0,0 +1,101 @@
+package envloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+)
+
+// Snapshot captures the current values of all variables, keyed by EnvKey,
+// for later comparison via Diff. The values are represented by SHA-256 hashes
+// of the underlying values (as returned by Get), so that values with masked
+// String forms, like FileContents, are compared faithfully without being exposed.
+func (vars VarSet) Snapshot() map[string]string {
+	m := make(map[string]string)
+	for _, vr := range vars {
+		if vr.isMarker() {
+			continue
+		}
+		m[vr.EnvKey] = vr.snapshotValue()
+	}
+	return m
+}
+
+func (vr *Var) snapshotValue() string {
+	h := sha256.Sum256([]byte(fingerprint(vr.Value)))
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// fingerprint returns a string that changes whenever the underlying value
+// of v changes, even if its String form is masked or lossy.
+func fingerprint(v flag.Value) string {
+	if lv, ok := v.(*LazyValue); ok {
+		if raw, pending := lv.state(); pending {
+			return "pending:" + raw
+		}
+		return fingerprint(lv.v)
+	}
+	if g, ok := v.(flag.Getter); ok {
+		value := g.Get()
+		if b, err := json.Marshal(value); err == nil {
+			return fmt.Sprintf("%T:%s", value, b)
+		}
+		return fmt.Sprintf("%T:%#v", value, value)
+	}
+	return v.String()
+}
+
+// Diff returns the keys of the variables whose values differ from the given
+// snapshot taken via Snapshot, in the order of the set.
+func (vars VarSet) Diff(old map[string]string) []string {
+	var keys []string
+	for _, vr := range vars {
+		if vr.isMarker() {
+			continue
+		}
+		if prev, ok := old[vr.EnvKey]; !ok || prev != vr.snapshotValue() {
+			keys = append(keys, vr.EnvKey)
+		}
+	}
+	return keys
+}
+
+// AsMap returns the current string values of all variables, keyed by EnvKey,
+// including defaults of the ones that have not been specified, e.g. for
+// rendering config files of other processes via text/template.
+// Values of Secret variables are masked; use AsMapUnmasked to include them.
+func (vars VarSet) AsMap() map[string]string {
+	m := make(map[string]string)
+	for _, vr := range vars {
+		if vr.isMarker() {
+			continue
+		}
+		for _, a := range vr.assignments(nil) {
+			m[a[0]] = a[1]
+		}
+	}
+	return m
+}
+
+// AsMapUnmasked is like AsMap, but includes the actual values of Secret variables.
+func (vars VarSet) AsMapUnmasked() map[string]string {
+	m := make(map[string]string)
+	for _, vr := range vars {
+		if vr.isMarker() {
+			continue
+		}
+		for _, a := range vr.unmaskedAssignments() {
+			m[a[0]] = a[1]
+		}
+	}
+	return m
+}
+
+func (vr *Var) unmaskedAssignments() [][2]string {
+	if mv, ok := vr.Value.(multiVar); ok {
+		return mv.assignments(vr.EnvKey)
+	}
+	return [][2]string{{vr.EnvKey, vr.Value.String()}}
+}
@@ -0,0 +1,50 @@
+package envloader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffFileContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pw")
+	os.WriteFile(path, []byte("one\n"), 0o600)
+
+	var pw string
+	var vars VarSet
+	vars.Var("PW_FILE", Required, FileContentsVar(&pw), "")
+	env := map[string]string{"PW_FILE": path}
+	if err := vars.TryParseMap(env); err != nil {
+		t.Fatal(err)
+	}
+	snap := vars.Snapshot()
+
+	if err := vars.TryParseMap(env); err != nil {
+		t.Fatal(err)
+	}
+	if diff := vars.Diff(snap); len(diff) != 0 {
+		t.Errorf("Diff = %v, wanted no changes", diff)
+	}
+
+	os.WriteFile(path, []byte("two\n"), 0o600)
+	if err := vars.TryParseMap(env); err != nil {
+		t.Fatal(err)
+	}
+	if diff := vars.Diff(snap); !reflect.DeepEqual(diff, []string{"PW_FILE"}) {
+		t.Errorf("Diff = %v, wanted [PW_FILE]", diff)
+	}
+}
+
+func TestSnapshotHidesValues(t *testing.T) {
+	var token string
+	var vars VarSet
+	vars.Var("TOKEN", Required, MaskedStringVar(&token), "").Secret()
+	vars.TryParseMap(map[string]string{"TOKEN": "sk_live_abcdefgh12345678"})
+	for key, v := range vars.Snapshot() {
+		if strings.Contains(v, "abcdefgh") || !strings.HasPrefix(v, "sha256:") {
+			t.Errorf("%s = %q, wanted a hash", key, v)
+		}
+	}
+}
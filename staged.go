@@ -0,0 +1,183 @@
+package envloader
+
+import (
+	"flag"
+	"reflect"
+)
+
+// ParseStaged is like TryParseFrom, but validates the new environment on its own:
+// it first resets all variables to their declared defaults (except the ones set
+// via BindFlags flags), so that a variable removed from the environment
+// is noticed, e.g. by its Required func. When parsing fails, it rolls back all
+// variables to the values (and IsSpecified flags) they had before the call,
+// so that a bad reload, e.g. on SIGHUP, leaves the running configuration intact.
+// AfterParse hooks only run when the new configuration is valid.
+// Returns whether the new values have been kept.
+//
+// The bound variables are still written during parsing, so code reading them
+// from other goroutines must be synchronized with the reload. For configuration
+// read concurrently, prefer the pointer swap pattern: keep the configuration in
+// a struct published via an atomic.Pointer, and on reload, declare a fresh VarSet
+// bound to a new copy of the struct, parse it, and only store the new pointer
+// when parsing succeeds.
+func (vars VarSet) ParseStaged(getenv func(string) string) (committed bool, err *Error) {
+	state := vars.Capture()
+	for _, vr := range vars {
+		if !vr.isMarker() {
+			vr.resetToDefault()
+		}
+	}
+	err = vars.TryParseFrom(getenv)
+	if err != nil {
+		vars.Restore(state)
+		return false, err
+	}
+	return true, nil
+}
+
+// State is a snapshot of the values of a VarSet taken by Capture.
+type State struct {
+	restores []func()
+}
+
+// Capture takes a snapshot of the values and IsSpecified flags of all variables,
+// e.g. to undo changes made by a test or a failed reload via Restore.
+// The values are copied rather than saved as strings, so masked or lossy
+// String forms do not matter. Only values defined outside this package
+// that are not simple named types, like structs, are saved via String and
+// restored via Set.
+func (vars VarSet) Capture() State {
+	var state State
+	for _, vr := range vars {
+		if !vr.isMarker() {
+			state.restores = append(state.restores, vr.saveState())
+		}
+	}
+	return state
+}
+
+// Restore reinstates the values and IsSpecified flags of the variables
+// captured by Capture. A state can be restored several times.
+func (vars VarSet) Restore(state State) {
+	for i := len(state.restores) - 1; i >= 0; i-- {
+		state.restores[i]()
+	}
+}
+
+// stater is implemented by values that cannot be saved by copying the variable
+// they point to, or by String and Set, to save and restore their state for ParseStaged.
+// All struct-based value types of this package implement it.
+type stater interface {
+	saveState() (restore func())
+}
+
+func saveState[T any](p *T) func() {
+	saved := *p
+	return func() {
+		*p = saved
+	}
+}
+
+func (vr *Var) saveState() func() {
+	specified := vr.IsSpecified
+	value := vr.Value
+	restore := valueState(value)
+	return func() {
+		vr.Value = value
+		restore()
+		vr.IsSpecified = specified
+	}
+}
+
+// valueState saves the state of value, returning a func that restores it.
+func valueState(value flag.Value) func() {
+	if st, ok := value.(stater); ok {
+		return st.saveState()
+	} else if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer && rv.Elem().Kind() != reflect.Struct {
+		// a named type like String or IntSlice; copy the variable itself
+		saved := reflect.New(rv.Elem().Type()).Elem()
+		saved.Set(rv.Elem())
+		return func() {
+			rv.Elem().Set(saved)
+		}
+	}
+	s := value.String()
+	return func() {
+		value.Set(s)
+	}
+}
+
+// resetToDefault brings the variable back to its state right after
+// the declaration, so that a reparse does not see the values of the
+// previous one. Variables set via flags keep their values.
+func (vr *Var) resetToDefault() {
+	if vr.fromFlag {
+		return
+	}
+	switch {
+	case vr.reset != nil:
+		vr.reset()
+	case vr.def == "":
+		vr.clear()
+	default:
+		vr.Value.Set(vr.def)
+	}
+	vr.IsSpecified = false
+}
+
+func (v *StringSet) saveState() func() {
+	if *v == nil {
+		return func() { *v = nil }
+	}
+	saved := v.Clone().(*StringSet)
+	return func() {
+		*v = *saved.Clone().(*StringSet)
+	}
+}
+
+func (v *LazyValue) saveState() func() {
+	v.mu.Lock()
+	saved := *v
+	v.mu.Unlock()
+	restoreInner := (&Var{Value: v.v}).saveState()
+	return func() {
+		restoreInner()
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		*v = saved
+	}
+}
+
+func (v *indexedStructs[T]) saveState() func() {
+	items, printed := *v.v, v.printed
+	return func() {
+		*v.v, v.printed = items, printed
+	}
+}
+
+func (v *FileContents) saveState() func() {
+	s, path := *v.v, v.path
+	return func() {
+		*v.v, v.path = s, path
+	}
+}
+
+func (v *Pattern) saveState() func()         { return saveState(v.v) }
+func (v *MaskedString) saveState() func()    { return saveState(v.v) }
+func (v *Path) saveState() func()            { return saveState(v.v) }
+func (v *HostPortList) saveState() func()    { return saveState(v.v) }
+func (v *BoolWith) saveState() func()        { return saveState(v.v) }
+func (v *EnumSet) saveState() func()         { return saveState(v.v) }
+func (v *Mapped) saveState() func()          { return saveState(v.v) }
+func (v *Base64) saveState() func()          { return saveState(v.v) }
+func (v *BoundedDuration) saveState() func() { return saveState(v.v) }
+func (v *IntBase) saveState() func()         { return saveState(v.v) }
+func (v *BoundedInt) saveState() func()      { return saveState(v.v) }
+func (v *Percent) saveState() func()         { return saveState(v.v) }
+func (v *NullBool) saveState() func()        { return saveState(v.v) }
+func (v *Fields) saveState() func()          { return saveState(v.v) }
+func (v *indexedStrings) saveState() func()  { return saveState(v.v) }
+func (v *JSONValue[T]) saveState() func()    { return saveState(v.v) }
+func (v *EnumTable[T]) saveState() func()    { return saveState(v.v) }
+func (v *BoolOrDuration) saveState() func()  { return saveState(v) }
+func (v *SemVer) saveState() func()          { return saveState(v) }
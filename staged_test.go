@@ -0,0 +1,66 @@
+package envloader
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleVarSet_ParseStaged() {
+	var host string
+	port := 8080
+	var vars VarSet
+	vars.Var("HOST", Required, StringVar(&host), "server host")
+	vars.Var("PORT", Optional, IntVar(&port), "server port")
+
+	env := map[string]string{"HOST": "example.com", "PORT": "9000"}
+	getenv := func(key string) string { return env[key] }
+	committed, err := vars.ParseStaged(getenv)
+	fmt.Println(committed, err == nil, host, port)
+
+	// a bad reload keeps the running configuration
+	env = map[string]string{"PORT": "9001"}
+	committed, err = vars.ParseStaged(getenv)
+	fmt.Println(committed, err.MissingVars[0].EnvKey, host, port)
+
+	// removing an optional variable brings back its default
+	env = map[string]string{"HOST": "example.org"}
+	committed, _ = vars.ParseStaged(getenv)
+	fmt.Println(committed, host, port)
+
+	// Output:
+	// true true example.com 9000
+	// false HOST example.com 9000
+	// true example.org 8080
+}
+
+func TestParseStagedNoticesRemovedRequired(t *testing.T) {
+	var host string
+	var vars VarSet
+	vars.Var("HOST", Required, StringVar(&host), "")
+
+	if committed, err := vars.ParseStaged(func(string) string { return "x" }); !committed || err != nil {
+		t.Fatalf("first parse: committed=%v err=%v", committed, err)
+	}
+	committed, err := vars.ParseStaged(func(string) string { return "" })
+	if committed || err == nil {
+		t.Fatalf("committed=%v err=%v, wanted a missing HOST", committed, err)
+	}
+	if host != "x" || !vars[0].IsSpecified {
+		t.Errorf("host=%q IsSpecified=%v, wanted the previous state", host, vars[0].IsSpecified)
+	}
+}
+
+func TestParseStagedLazy(t *testing.T) {
+	var host string
+	var vars VarSet
+	vars.Var("HOST", Optional, StringVar(&host), "").Lazy()
+
+	vars.ParseStaged(func(string) string { return "x" })
+	if got := vars[0].Value.(*LazyValue).Get(); got != "x" {
+		t.Fatalf("got %v, wanted x", got)
+	}
+	vars.ParseStaged(func(string) string { return "" })
+	if got := vars[0].Value.String(); got != "" {
+		t.Errorf("got %q, wanted the empty default", got)
+	}
+}
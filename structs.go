@@ -0,0 +1,110 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FromStruct declares a variable for every field of the struct pointed to by ptr
+// that has an env tag. The tag holds the key, optionally followed by comma-separated
+// flags: required and secret. The envDefault tag holds a default value that
+// is parsed right away, and envDesc holds the description:
+//
+//	type Config struct {
+//		DBHost string `env:"DB_HOST,required" envDefault:"localhost" envDesc:"database host"`
+//	}
+//
+// Supported field types are string, int, int64, float64, bool, time.Duration,
+// and any type whose pointer implements flag.Value. Fields of exported embedded
+// structs are declared too. Fields tagged env:"-" are skipped, and a tag without
+// a key, like env:",required", is an error.
+func (vars *VarSet) FromStruct(ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("envloader: FromStruct requires a pointer to a struct, got %T", ptr)
+	}
+	return vars.fromStruct(rv.Elem())
+}
+
+func (vars *VarSet) fromStruct(sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if tag == "-" {
+			continue
+		}
+		if !ok {
+			if field.Anonymous && field.IsExported() && field.Type.Kind() == reflect.Struct {
+				if err := vars.fromStruct(sv.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("envloader: field %s.%s is not exported", st.Name(), field.Name)
+		}
+
+		key, flags, _ := strings.Cut(tag, ",")
+		if key == "" {
+			return fmt.Errorf("envloader: field %s.%s has an empty env key in tag %q", st.Name(), field.Name, tag)
+		}
+		required := Optional
+		var secret bool
+		for _, f := range strings.Split(flags, ",") {
+			switch f {
+			case "":
+			case "required":
+				required = Required
+			case "secret":
+				secret = true
+			default:
+				return fmt.Errorf("envloader: field %s.%s has unknown env tag flag %q", st.Name(), field.Name, f)
+			}
+		}
+
+		value := structFieldValue(sv.Field(i))
+		if value == nil {
+			return fmt.Errorf("envloader: field %s.%s has unsupported type %v", st.Name(), field.Name, field.Type)
+		}
+		if def, ok := field.Tag.Lookup("envDefault"); ok {
+			if err := value.Set(def); err != nil {
+				return fmt.Errorf("envloader: field %s.%s has invalid default: %w", st.Name(), field.Name, err)
+			}
+		}
+
+		vr := vars.Var(key, required, value, field.Tag.Get("envDesc"))
+		if secret {
+			vr.Secret()
+		}
+	}
+	return nil
+}
+
+func structFieldValue(fv reflect.Value) flag.Value {
+	if v, ok := fv.Addr().Interface().(flag.Value); ok {
+		return v
+	}
+	if fv.Type() == durationType {
+		return DurationVar(fv.Addr().Interface().(*time.Duration))
+	}
+	switch p := fv.Addr().Interface().(type) {
+	case *string:
+		return StringVar(p)
+	case *int:
+		return IntVar(p)
+	case *int64:
+		return Int64Var(p)
+	case *float64:
+		return Float64Var(p)
+	case *bool:
+		return BoolVar(p)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+type structsInner struct {
+	Inner string `env:"INNER"`
+}
+
+type StructsOuter struct {
+	Outer string `env:"OUTER"`
+}
+
+func TestFromStructSkips(t *testing.T) {
+	cfg := struct {
+		structsInner
+		StructsOuter
+		Skipped string `env:"-"`
+		Name    string `env:"NAME,required"`
+	}{}
+	var vars VarSet
+	if err := vars.FromStruct(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, vr := range vars {
+		keys = append(keys, vr.EnvKey)
+	}
+	if got, want := strings.Join(keys, " "), "OUTER NAME"; got != want {
+		t.Errorf("declared %q, wanted %q", got, want)
+	}
+}
+
+func TestFromStructEmptyKey(t *testing.T) {
+	cfg := struct {
+		Name string `env:",required"`
+	}{}
+	var vars VarSet
+	err := vars.FromStruct(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "Name has an empty env key") {
+		t.Errorf("got %v, wanted an empty key error", err)
+	}
+	if len(vars) != 0 {
+		t.Errorf("declared %d variables", len(vars))
+	}
+}
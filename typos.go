@@ -0,0 +1,149 @@
+package envloader
+
+import "strings"
+
+// DetectTypos makes TryParseEnviron warn about environment keys that are not
+// declared in the set but are within a small edit distance of a declared key,
+// like DB_HST for DB_HOST. Short keys (under 8 characters) allow one edit,
+// longer ones two. Warnings go to the OnWarning function and never fail parsing.
+//
+// Detection requires the full environment, so it does nothing when parsing
+// via a getenv function.
+func (vars *VarSet) DetectTypos() {
+	vars.configure(func(s *settings) {
+		s.detectTypos = true
+	})
+}
+
+func (vars VarSet) warnTypos(environ []string, s *settings) {
+	seen := make(map[string]bool)
+	for _, entry := range environ {
+		key, _, ok := strings.Cut(entry, "=")
+		if !ok || seen[key] || vars.isKnownKey(key, false) {
+			continue
+		}
+		seen[key] = true
+		if suggestion := vars.suggestKey(key, s.caseInsensitive); suggestion != "" {
+			s.warnf("%s is set but not recognized; did you mean %s?", key, suggestion)
+		}
+	}
+}
+
+func (vars VarSet) suggestKey(key string, caseInsensitive bool) string {
+	var best string
+	bestDist := -1
+	for _, vr := range vars {
+		if vr.isMarker() {
+			continue
+		}
+		if caseInsensitive && strings.EqualFold(key, vr.EnvKey) {
+			return ""
+		}
+		limit := 1
+		if len(vr.EnvKey) >= 8 {
+			limit = 2
+		}
+		d := levenshtein(key, vr.EnvKey)
+		if d <= limit && (bestDist < 0 || d < bestDist) {
+			best, bestDist = vr.EnvKey, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// StrictPrefix makes TryParseEnviron report environment keys that start with
+// the given prefix but are not declared in the set, like a stale or misspelled
+// MYAPP_TYPOED, via Error.UnknownKeys.
+//
+// This requires the full environment, so it does nothing when parsing
+// via a getenv function.
+func (vars *VarSet) StrictPrefix(prefix string) {
+	vars.configure(func(s *settings) {
+		s.prefixes = append(s.prefixes, prefixRule{prefix, false})
+	})
+}
+
+// WarnPrefix is like StrictPrefix, but reports unknown keys as warnings
+// via the OnWarning function instead of failing.
+func (vars *VarSet) WarnPrefix(prefix string) {
+	vars.configure(func(s *settings) {
+		s.prefixes = append(s.prefixes, prefixRule{prefix, true})
+	})
+}
+
+type prefixRule struct {
+	prefix string
+	warn   bool
+}
+
+func (vars VarSet) checkPrefixes(environ []string, s *settings, e *Error) {
+	seen := make(map[string]bool)
+	for _, entry := range environ {
+		key, _, ok := strings.Cut(entry, "=")
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if vars.isKnownKey(key, s.caseInsensitive) {
+			continue
+		}
+		for _, rule := range s.prefixes {
+			if !strings.HasPrefix(key, rule.prefix) {
+				continue
+			}
+			if rule.warn {
+				s.warnf("%s is set but not recognized", key)
+			} else {
+				e.UnknownKeys = append(e.UnknownKeys, key)
+			}
+			break
+		}
+	}
+}
+
+// isKnownKey returns whether key is declared in the set, either directly
+// or as one of the numbered keys of an IndexedStrings or Indexed variable.
+func (vars VarSet) isKnownKey(key string, caseInsensitive bool) bool {
+	if vars.lookup(key) != nil || (caseInsensitive && vars.lookupFold(key) != nil) {
+		return true
+	}
+	for _, vr := range vars {
+		if _, ok := vr.Value.(multiVar); !ok || len(key) <= len(vr.EnvKey) {
+			continue
+		}
+		prefix, rest := key[:len(vr.EnvKey)], key[len(vr.EnvKey):]
+		if (prefix == vr.EnvKey || caseInsensitive && strings.EqualFold(prefix, vr.EnvKey)) && rest[0] >= '0' && rest[0] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+func (vars VarSet) lookupFold(key string) *Var {
+	for _, vr := range vars {
+		if !vr.isMarker() && strings.EqualFold(vr.EnvKey, key) {
+			return vr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package envloader
+
+import "testing"
+
+func TestStrictPrefixIndexed(t *testing.T) {
+	var upstreams []string
+	var vars VarSet
+	vars.IndexedStrings("MYAPP_UPSTREAM_", &upstreams)
+	vars.StrictPrefix("MYAPP_")
+
+	err := vars.TryParseEnviron([]string{"MYAPP_UPSTREAM_0=a", "MYAPP_UPSTREAM_1=b", "MYAPP_TYPOED=x"})
+	if err == nil || len(err.UnknownKeys) != 1 || err.UnknownKeys[0] != "MYAPP_TYPOED" {
+		t.Fatalf("got %v, wanted only MYAPP_TYPOED to be unknown", err)
+	}
+	if len(upstreams) != 2 {
+		t.Errorf("upstreams = %v", upstreams)
+	}
+}
+
+func TestDetectTyposIndexed(t *testing.T) {
+	var upstreams []string
+	var host string
+	var warnings []string
+	var vars VarSet
+	vars.OnWarning(func(msg string) { warnings = append(warnings, msg) })
+	vars.DetectTypos()
+	vars.IndexedStrings("MYAPP_UPSTREAM_", &upstreams)
+	vars.Var("MYAPP_HOST", Optional, StringVar(&host), "")
+
+	vars.TryParseEnviron([]string{"MYAPP_UPSTREAM_0=a", "MYAPP_UPSTREAM_1=b", "MYAPP_HST=x"})
+	if len(warnings) != 1 || warnings[0] != "MYAPP_HST is set but not recognized; did you mean MYAPP_HOST?" {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
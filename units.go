@@ -0,0 +1,236 @@
+package envloader
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses sizes like 512, 64KB, 1.5GB or 10MiB. Units are binary:
+// K, KB and KiB all mean 1024 bytes.
+func parseByteSize(raw string) (int64, error) {
+	s := strings.TrimSpace(raw)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.')
+	})
+	num, unit := s, ""
+	if i >= 0 {
+		num, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+	if num == "" {
+		return 0, fmt.Errorf("expected a size like 512, 64KB or 1.5GB")
+	}
+	mult := int64(-1)
+	switch strings.ToUpper(unit) {
+	case "", "B":
+		mult = 1
+	case "K", "KB", "KIB":
+		mult = 1 << 10
+	case "M", "MB", "MIB":
+		mult = 1 << 20
+	case "G", "GB", "GIB":
+		mult = 1 << 30
+	case "T", "TB", "TIB":
+		mult = 1 << 40
+	}
+	if mult < 0 {
+		return 0, fmt.Errorf("unknown size unit %q, expected B, KB, MB, GB or TB", unit)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", num)
+	}
+	n := f * float64(mult)
+	if n >= math.MaxInt64 {
+		return 0, fmt.Errorf("size is too large")
+	}
+	return int64(math.Round(n)), nil
+}
+
+// formatByteSize renders n using the largest unit that represents it exactly.
+func formatByteSize(n int64) string {
+	if n == 0 {
+		return "0"
+	}
+	for _, u := range byteUnits {
+		if n%u.size == 0 {
+			return strconv.FormatInt(n/u.size, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+// ByteSizeVar returns a value that parses sizes in bytes like 512, 64KB,
+// 1.5GB or 10MiB. Units are binary, so KB and KiB both mean 1024 bytes.
+func ByteSizeVar(v *int64) *ByteSize {
+	return (*ByteSize)(v)
+}
+
+type ByteSize int64
+
+func (v ByteSize) String() string {
+	return formatByteSize(int64(v))
+}
+
+func (v ByteSize) Get() interface{} {
+	return int64(v)
+}
+
+func (v *ByteSize) Set(raw string) error {
+	n, err := parseByteSize(raw)
+	if err != nil {
+		return err
+	}
+	*v = ByteSize(n)
+	return nil
+}
+
+func (v *ByteSize) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *ByteSize) TypeName() string {
+	return "bytesize"
+}
+
+// RateVar returns a value that parses transfer rates like 10MB/s, 500KB/m
+// or 1GB/h, see ByteSizeVar for the size units, and stores them in bytes
+// per second, rounded to the nearest integer.
+func RateVar(v *int64) *Rate {
+	return (*Rate)(v)
+}
+
+type Rate int64
+
+func (v Rate) String() string {
+	return formatByteSize(int64(v)) + "/s"
+}
+
+func (v Rate) Get() interface{} {
+	return int64(v)
+}
+
+func (v *Rate) Set(raw string) error {
+	size, base, ok := strings.Cut(raw, "/")
+	if !ok {
+		return fmt.Errorf("expected a rate like 10MB/s, missing the time base")
+	}
+	var seconds float64
+	switch strings.TrimSpace(base) {
+	case "s":
+		seconds = 1
+	case "m":
+		seconds = 60
+	case "h":
+		seconds = 3600
+	default:
+		return fmt.Errorf("unknown time base %q, expected s, m or h", base)
+	}
+	size = strings.TrimSpace(size)
+	if size != "" && size[len(size)-1] >= '0' && size[len(size)-1] <= '9' {
+		return fmt.Errorf("expected a rate like 10MB/s, missing the size unit")
+	}
+	n, err := parseByteSize(size)
+	if err != nil {
+		return err
+	}
+	*v = Rate(math.Round(float64(n) / seconds))
+	return nil
+}
+
+func (v *Rate) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Rate) TypeName() string {
+	return "rate"
+}
+
+var siPrefixes = []struct {
+	suffix string
+	mult   int64
+}{
+	{"T", 1e12},
+	{"G", 1e9},
+	{"M", 1e6},
+	{"k", 1e3},
+}
+
+// SIQuantityVar returns a value that parses counts with decimal SI prefixes,
+// like 2k or 1.5M. The prefixes k, M, G and T are base-1000, unlike the
+// binary units of ByteSizeVar, and are case-sensitive: m and K are rejected
+// as ambiguous.
+func SIQuantityVar(v *int64) *SIQuantity {
+	return (*SIQuantity)(v)
+}
+
+type SIQuantity int64
+
+func (v SIQuantity) String() string {
+	n := int64(v)
+	if n != 0 {
+		for _, p := range siPrefixes {
+			if n%p.mult == 0 {
+				return strconv.FormatInt(n/p.mult, 10) + p.suffix
+			}
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func (v SIQuantity) Get() interface{} {
+	return int64(v)
+}
+
+func (v *SIQuantity) Set(raw string) error {
+	num, mult := raw, int64(1)
+	for _, p := range siPrefixes {
+		if strings.HasSuffix(raw, p.suffix) {
+			num, mult = strings.TrimSuffix(raw, p.suffix), p.mult
+			break
+		}
+	}
+	if mult == 1 && raw != "" {
+		switch raw[len(raw)-1] {
+		case 'm', 'K', 'g', 't':
+			return fmt.Errorf("ambiguous suffix %q, expected one of k, M, G, T", raw[len(raw)-1:])
+		}
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || !isFinite(f) {
+		return fmt.Errorf("expected a number like 500, 2k or 1.5M")
+	}
+	n := f * float64(mult)
+	if math.Abs(n) >= math.MaxInt64 {
+		return fmt.Errorf("value is too large")
+	}
+	if n != math.Trunc(n) {
+		return fmt.Errorf("must be a whole number")
+	}
+	*v = SIQuantity(n)
+	return nil
+}
+
+func (v *SIQuantity) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *SIQuantity) TypeName() string {
+	return "quantity"
+}
@@ -0,0 +1,117 @@
+package envloader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int64
+		str  string
+		err  string
+	}{
+		{"512", 512, "512B", ""},
+		{"0", 0, "0", ""},
+		{"64KB", 64 << 10, "64KB", ""},
+		{"64 kb", 64 << 10, "64KB", ""},
+		{"10MiB", 10 << 20, "10MB", ""},
+		{"1.5GB", 3 << 29, "1536MB", ""},
+		{"2T", 2 << 40, "2TB", ""},
+		{"1025B", 1025, "1025B", ""},
+		{"", 0, "", "expected a size like 512, 64KB or 1.5GB"},
+		{"KB", 0, "", "expected a size like 512, 64KB or 1.5GB"},
+		{"-1KB", 0, "", "expected a size like 512, 64KB or 1.5GB"},
+		{"10PB", 0, "", `unknown size unit "PB"`},
+		{"1.2.3MB", 0, "", `invalid size "1.2.3"`},
+		{"10000000TB", 0, "", "size is too large"},
+	}
+	for _, tt := range tests {
+		var n int64
+		v := ByteSizeVar(&n)
+		err := v.Set(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || n != tt.want || v.String() != tt.str {
+			t.Errorf("%q: got %d (%q), %v, wanted %d (%q)", tt.raw, n, v.String(), err, tt.want, tt.str)
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int64
+		str  string
+		err  string
+	}{
+		{"10MB/s", 10 << 20, "10MB/s", ""},
+		{"60KB/m", 1 << 10, "1KB/s", ""},
+		{"1GB / h", 298262, "298262B/s", ""},
+		{"1B/m", 0, "0/s", ""},
+		{"10MB", 0, "", "missing the time base"},
+		{"10/s", 0, "", "missing the size unit"},
+		{"10MB/d", 0, "", `unknown time base "d"`},
+		{"10XB/s", 0, "", `unknown size unit "XB"`},
+	}
+	for _, tt := range tests {
+		var n int64
+		v := RateVar(&n)
+		err := v.Set(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || n != tt.want || v.String() != tt.str {
+			t.Errorf("%q: got %d (%q), %v, wanted %d (%q)", tt.raw, n, v.String(), err, tt.want, tt.str)
+		}
+	}
+}
+
+func TestSIQuantity(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int64
+		str  string
+		err  string
+	}{
+		{"500", 500, "500", ""},
+		{"0", 0, "0", ""},
+		{"2k", 2000, "2k", ""},
+		{"1.5M", 1500000, "1500k", ""},
+		{"3G", 3e9, "3G", ""},
+		{"1T", 1e12, "1T", ""},
+		{"-2k", -2000, "-2k", ""},
+		{"1001", 1001, "1001", ""},
+		{"2K", 0, "", `ambiguous suffix "K"`},
+		{"2m", 0, "", `ambiguous suffix "m"`},
+		{"1.5", 0, "", "must be a whole number"},
+		{"1.0005k", 0, "", "must be a whole number"},
+		{"k", 0, "", "expected a number like 500, 2k or 1.5M"},
+		{"", 0, "", "expected a number like 500, 2k or 1.5M"},
+		{"10000000T", 0, "", "value is too large"},
+		{"NaN", 0, "", "expected a number like 500, 2k or 1.5M"},
+		{"Inf", 0, "", "expected a number like 500, 2k or 1.5M"},
+	}
+	for _, tt := range tests {
+		var n int64
+		v := SIQuantityVar(&n)
+		err := v.Set(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: got error %v, wanted %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || n != tt.want || v.String() != tt.str {
+			t.Errorf("%q: got %d (%q), %v, wanted %d (%q)", tt.raw, n, v.String(), err, tt.want, tt.str)
+		}
+	}
+}
@@ -1,12 +1,28 @@
 package envloader
 
 import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Value is implemented by all value types of this package. VarSet.Var accepts
+// any flag.Value though, including ones from other libraries: values that do not
+// implement flag.Getter are reported as their String by Var.Interface, values
+// without Cloner are shared by VarSet.Clone, and values without TypeNamer
+// are described by their Go type name.
 type Value interface {
 	flag.Value
 	flag.Getter
@@ -36,94 +52,1131 @@ func (v *String) Set(raw string) error {
 	return nil
 }
 
+func (v *String) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *String) TypeName() string {
+	return "string"
+}
+
+// MaskedStringVar returns a value for secrets like API tokens whose String
+// shows only the first and last 4 characters, e.g. sk_l…1234, so that logs and
+// PrintTo tell apart which key is loaded without exposing it. Values shorter
+// than twice the revealed characters are masked entirely. Get returns the full value.
+// Because String masks, so do the unmasked outputs like AsMapUnmasked.
+// Use Show to change the number of revealed characters.
+func MaskedStringVar(v *string) *MaskedString {
+	return &MaskedString{v, 4, 4}
+}
+
+type MaskedString struct {
+	v              *string
+	prefix, suffix int
+}
+
+// Show sets the number of leading and trailing characters that String reveals.
+func (v *MaskedString) Show(prefix, suffix int) *MaskedString {
+	v.prefix, v.suffix = prefix, suffix
+	return v
+}
+
+func (v *MaskedString) String() string {
+	if v.v == nil || *v.v == "" {
+		return ""
+	}
+	r := []rune(*v.v)
+	if len(r) < 2*(v.prefix+v.suffix) {
+		return masked
+	}
+	return string(r[:v.prefix]) + "…" + string(r[len(r)-v.suffix:])
+}
+
+// Redacted makes Secret variables render the partial mask instead of ***.
+func (v *MaskedString) Redacted() string {
+	return v.String()
+}
+
+func (v *MaskedString) Get() interface{} {
+	return *v.v
+}
+
+func (v *MaskedString) Set(raw string) error {
+	*v.v = raw
+	return nil
+}
+
+func (v *MaskedString) Clone() flag.Value {
+	c := *v.v
+	return &MaskedString{&c, v.prefix, v.suffix}
+}
+
+func (v *MaskedString) TypeName() string {
+	return "string"
+}
+
+// NonEmptyStringVar returns a value that rejects empty and whitespace-only strings.
+func NonEmptyStringVar(v *string) *NonEmptyString {
+	return (*NonEmptyString)(v)
+}
+
+type NonEmptyString string
+
+func (v NonEmptyString) String() string {
+	return string(v)
+}
+
+func (v NonEmptyString) Get() interface{} {
+	return string(v)
+}
+
+func (v *NonEmptyString) Set(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	*v = NonEmptyString(raw)
+	return nil
+}
+
+func (v *NonEmptyString) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *NonEmptyString) TypeName() string {
+	return "string"
+}
+
+// UUIDVar returns a value that accepts UUIDs in the canonical
+// 8-4-4-4-12 hex format, like 550e8400-e29b-41d4-a716-446655440000,
+// and stores them in lower case.
+func UUIDVar(v *string) *UUID {
+	return (*UUID)(v)
+}
+
+type UUID string
+
+func (v UUID) String() string {
+	return string(v)
+}
+
+func (v UUID) Get() interface{} {
+	return string(v)
+}
+
+// Bytes returns the 16 bytes of the UUID, or all zeros when unset.
+func (v UUID) Bytes() [16]byte {
+	var b [16]byte
+	hex.Decode(b[:], []byte(strings.ReplaceAll(string(v), "-", "")))
+	return b
+}
+
+func (v *UUID) Set(raw string) error {
+	if !uuidRe.MatchString(raw) {
+		return fmt.Errorf("invalid UUID, expected xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx")
+	}
+	*v = UUID(strings.ToLower(raw))
+	return nil
+}
+
+func (v *UUID) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *UUID) TypeName() string {
+	return "uuid"
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// LuhnNumberVar returns a value for numeric identifiers protected by the Luhn
+// check digit, like payment card numbers. Spaces and dashes are stripped,
+// and the remaining digits are stored. Use Secret for real card numbers.
+func LuhnNumberVar(v *string) *LuhnNumber {
+	return (*LuhnNumber)(v)
+}
+
+type LuhnNumber string
+
+func (v LuhnNumber) String() string {
+	return string(v)
+}
+
+func (v LuhnNumber) Get() interface{} {
+	return string(v)
+}
+
+func (v *LuhnNumber) Set(raw string) error {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, raw)
+	if len(digits) < 2 {
+		return fmt.Errorf("expected a number with a check digit")
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return fmt.Errorf("must contain only digits, spaces and dashes")
+		}
+		if (len(digits)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	if sum%10 != 0 {
+		return fmt.Errorf("invalid check digit")
+	}
+	*v = LuhnNumber(digits)
+	return nil
+}
+
+func (v *LuhnNumber) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *LuhnNumber) TypeName() string {
+	return "luhn"
+}
+
+// PatternVar returns a value that only accepts strings matching re.
+func PatternVar(v *string, re *regexp.Regexp) *Pattern {
+	return &Pattern{v, re}
+}
+
+type Pattern struct {
+	v  *string
+	re *regexp.Regexp
+}
+
+func (v *Pattern) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return *v.v
+}
+
+func (v *Pattern) Get() interface{} {
+	return *v.v
+}
+
+func (v *Pattern) Set(raw string) error {
+	if !v.re.MatchString(raw) {
+		return fmt.Errorf("does not match pattern %s", v.re)
+	}
+	*v.v = raw
+	return nil
+}
+
+func (v *Pattern) Clone() flag.Value {
+	c := *v.v
+	return &Pattern{&c, v.re}
+}
+
+func (v *Pattern) TypeName() string {
+	return "string"
+}
+
+// MappedVar returns a value that accepts any of the keys of mapping,
+// case-insensitively, and stores the corresponding canonical value:
+//
+//	MappedVar(&format, map[string]string{"json": "json", "text": "text", "plain": "text", "console": "text"})
+func MappedVar(v *string, mapping map[string]string) *Mapped {
+	m := make(map[string]string, len(mapping))
+	for k, c := range mapping {
+		m[strings.ToLower(k)] = c
+	}
+	return &Mapped{v, m}
+}
+
+type Mapped struct {
+	v       *string
+	mapping map[string]string
+}
+
+func (v *Mapped) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return *v.v
+}
+
+func (v *Mapped) Get() interface{} {
+	return *v.v
+}
+
+func (v *Mapped) Set(raw string) error {
+	c, ok := v.mapping[strings.ToLower(raw)]
+	if !ok {
+		accepted := make([]string, 0, len(v.mapping))
+		for k := range v.mapping {
+			accepted = append(accepted, k)
+		}
+		sort.Strings(accepted)
+		return fmt.Errorf("must be one of %s", strings.Join(accepted, ", "))
+	}
+	*v.v = c
+	return nil
+}
+
+func (v *Mapped) Clone() flag.Value {
+	c := *v.v
+	return &Mapped{&c, v.mapping}
+}
+
+func (v *Mapped) TypeName() string {
+	return "string"
+}
+
+// FileContentsVar returns a value that treats the raw string as a file path
+// and stores the contents of that file, minus a trailing newline. This supports
+// the _FILE convention for secrets mounted into containers, so String masks
+// the contents.
+func FileContentsVar(v *string) *FileContents {
+	return &FileContents{v: v}
+}
+
+type FileContents struct {
+	v    *string
+	path string
+}
+
+func (v *FileContents) String() string {
+	if v.v == nil || *v.v == "" {
+		return ""
+	}
+	return "..."
+}
+
+func (v *FileContents) Get() interface{} {
+	return *v.v
+}
+
+func (v *FileContents) Set(raw string) error {
+	data, err := os.ReadFile(raw)
+	if err != nil {
+		return err
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	s = strings.TrimSuffix(s, "\r")
+	*v.v = s
+	v.path = raw
+	return nil
+}
+
+func (v *FileContents) Clone() flag.Value {
+	c := *v.v
+	return &FileContents{&c, v.path}
+}
+
+func (v *FileContents) TypeName() string {
+	return "file"
+}
+
+// FilePathVar returns a value that accepts paths of existing regular files.
+func FilePathVar(v *string) *Path {
+	return &Path{v: v, dir: false}
+}
+
+// DirPathVar returns a value that accepts paths of existing directories.
+func DirPathVar(v *string) *Path {
+	return &Path{v: v, dir: true}
+}
+
+type Path struct {
+	v            *string
+	dir          bool
+	abs          bool
+	allowMissing bool
+}
+
+// Abs makes Set store the absolute form of the path.
+func (v *Path) Abs() *Path {
+	v.abs = true
+	return v
+}
+
+// AllowMissing makes Set accept paths that do not exist, e.g. for output
+// directories that will be created. Existing paths must still be of the right type.
+func (v *Path) AllowMissing() *Path {
+	v.allowMissing = true
+	return v
+}
+
+func (v *Path) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return *v.v
+}
+
+func (v *Path) Get() interface{} {
+	return *v.v
+}
+
+func (v *Path) Set(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("path must not be empty")
+	}
+	path := raw
+	if v.abs {
+		var err error
+		path, err = filepath.Abs(raw)
+		if err != nil {
+			return err
+		}
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		if !(v.allowMissing && os.IsNotExist(err)) {
+			return err
+		}
+	} else if v.dir && !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", raw)
+	} else if !v.dir && !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", raw)
+	}
+	*v.v = path
+	return nil
+}
+
+func (v *Path) Clone() flag.Value {
+	c := *v
+	p := *v.v
+	c.v = &p
+	return &c
+}
+
+func (v *Path) TypeName() string {
+	if v.dir {
+		return "dir"
+	}
+	return "path"
+}
+
+// Base64Var returns a value that decodes base64 data using the given encoding.
+// A nil encoding accepts both the standard and the URL-safe alphabets, with or
+// without padding, and re-encodes using base64.StdEncoding.
+func Base64Var(v *[]byte, enc *base64.Encoding) *Base64 {
+	return &Base64{v, enc}
+}
+
+type Base64 struct {
+	v   *[]byte
+	enc *base64.Encoding
+}
+
+func (v *Base64) String() string {
+	if v.v == nil || *v.v == nil {
+		return ""
+	}
+	enc := v.enc
+	if enc == nil {
+		enc = base64.StdEncoding
+	}
+	return enc.EncodeToString(*v.v)
+}
+
+func (v *Base64) Get() interface{} {
+	return *v.v
+}
+
+func (v *Base64) Set(raw string) error {
+	var data []byte
+	var err error
+	if v.enc != nil {
+		data, err = v.enc.DecodeString(raw)
+	} else {
+		raw = strings.TrimRight(raw, "=")
+		if strings.ContainsAny(raw, "-_") {
+			data, err = base64.RawURLEncoding.DecodeString(raw)
+		} else {
+			data, err = base64.RawStdEncoding.DecodeString(raw)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	*v.v = data
+	return nil
+}
+
+func (v *Base64) Clone() flag.Value {
+	c := append([]byte(nil), *v.v...)
+	return &Base64{&c, v.enc}
+}
+
+func (v *Base64) TypeName() string {
+	return "base64"
+}
+
 func NewDuration(v time.Duration) *Duration {
 	vv := Duration(v)
 	return &vv
 }
 
-func DurationVar(v *time.Duration) *Duration {
-	return (*Duration)(v)
+func DurationVar(v *time.Duration) *Duration {
+	return (*Duration)(v)
+}
+
+type Duration time.Duration
+
+func (v Duration) String() string {
+	return time.Duration(v).String()
+}
+
+func (v Duration) Get() interface{} {
+	return time.Duration(v)
+}
+
+func (v *Duration) Set(raw string) error {
+	p, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*v = Duration(p)
+	return nil
+}
+
+func (v *Duration) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Duration) TypeName() string {
+	return "duration"
+}
+
+// NonNegativeDurationVar returns a value that parses a duration and rejects
+// negative ones, which are almost always misconfigured timeouts.
+// Use Positive, Min and Max to tighten the accepted range.
+func NonNegativeDurationVar(v *time.Duration) *BoundedDuration {
+	return &BoundedDuration{v: v, max: math.MaxInt64}
+}
+
+type BoundedDuration struct {
+	v        *time.Duration
+	min, max time.Duration
+	positive bool
+}
+
+// Positive makes the value reject zero durations too.
+func (v *BoundedDuration) Positive() *BoundedDuration {
+	v.positive = true
+	return v
+}
+
+// Min sets the smallest accepted duration, catching typos like 5ns.
+func (v *BoundedDuration) Min(d time.Duration) *BoundedDuration {
+	v.min = d
+	return v
+}
+
+// Max sets the largest accepted duration.
+func (v *BoundedDuration) Max(d time.Duration) *BoundedDuration {
+	v.max = d
+	return v
+}
+
+func (v *BoundedDuration) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return v.v.String()
+}
+
+func (v *BoundedDuration) Get() interface{} {
+	return *v.v
+}
+
+func (v *BoundedDuration) Set(raw string) error {
+	p, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	switch {
+	case p < 0:
+		return fmt.Errorf("must not be negative")
+	case p == 0 && v.positive:
+		return fmt.Errorf("must not be zero")
+	case p < v.min:
+		return fmt.Errorf("must be at least %v", v.min)
+	case p > v.max:
+		return fmt.Errorf("must be at most %v", v.max)
+	}
+	*v.v = p
+	return nil
+}
+
+func (v *BoundedDuration) Clone() flag.Value {
+	c := *v.v
+	return &BoundedDuration{&c, v.min, v.max, v.positive}
+}
+
+func (v *BoundedDuration) TypeName() string {
+	return "duration"
+}
+
+func NewInt(v int) *Int {
+	vv := Int(v)
+	return &vv
+}
+
+func IntVar(v *int) *Int {
+	return (*Int)(v)
+}
+
+type Int int
+
+func (v Int) String() string {
+	return strconv.Itoa(int(v))
+}
+
+func (v Int) Get() interface{} {
+	return int(v)
+}
+
+func (v *Int) Set(raw string) error {
+	p, err := strconv.ParseInt(raw, 10, 0)
+	if err != nil {
+		return intError(raw, err, "int")
+	}
+	*v = Int(p)
+	return nil
+}
+
+func (v *Int) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Int) TypeName() string {
+	return "int"
+}
+
+// IntVarBase returns a value that parses an int in the given base, as per
+// strconv.ParseInt. Base 0 auto-detects the 0x, 0o and 0b prefixes (and a leading
+// 0 for octal), while still accepting plain decimals. Note that IntVar always
+// parses decimals, so 0644 means 644 there.
+func IntVarBase(v *int, base int) *IntBase {
+	return &IntBase{v, base}
+}
+
+type IntBase struct {
+	v    *int
+	base int
+}
+
+func (v *IntBase) String() string {
+	if v.v == nil {
+		return ""
+	}
+	switch v.base {
+	case 0, 10:
+		return strconv.Itoa(*v.v)
+	default:
+		return strconv.FormatInt(int64(*v.v), v.base)
+	}
+}
+
+func (v *IntBase) Get() interface{} {
+	return *v.v
+}
+
+func (v *IntBase) Set(raw string) error {
+	p, err := strconv.ParseInt(raw, v.base, 0)
+	if err != nil {
+		return intError(raw, err, "int")
+	}
+	*v.v = int(p)
+	return nil
+}
+
+func (v *IntBase) Clone() flag.Value {
+	c := *v.v
+	return &IntBase{&c, v.base}
+}
+
+// TypeName returns int for base 10, and int(baseN) otherwise, e.g. int(base16),
+// so that schemas know how to parse the value.
+func (v *IntBase) TypeName() string {
+	if v.base == 10 {
+		return "int"
+	}
+	return fmt.Sprintf("int(base%d)", v.base)
+}
+
+// FileModeVar returns a value that parses octal permission bits like 0644
+// (with an optional 0o prefix), up to 0777.
+func FileModeVar(v *os.FileMode) *FileMode {
+	return (*FileMode)(v)
+}
+
+type FileMode os.FileMode
+
+func (v FileMode) String() string {
+	return fmt.Sprintf("%04o", uint32(v))
+}
+
+func (v FileMode) Get() interface{} {
+	return os.FileMode(v)
+}
+
+func (v *FileMode) Set(raw string) error {
+	s := strings.TrimPrefix(strings.TrimPrefix(raw, "0o"), "0O")
+	p, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return err
+	}
+	if p > uint64(os.ModePerm) {
+		return fmt.Errorf("must be at most 0777")
+	}
+	*v = FileMode(p)
+	return nil
+}
+
+func (v *FileMode) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *FileMode) TypeName() string {
+	return "filemode"
+}
+
+// BoundedIntVar returns a value that parses an int within the inclusive range
+// from min to max. Pass math.MinInt or math.MaxInt to leave one side open.
+func BoundedIntVar(v *int, min, max int) *BoundedInt {
+	return &BoundedInt{v, min, max}
+}
+
+type BoundedInt struct {
+	v        *int
+	min, max int
+}
+
+func (v *BoundedInt) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v.v)
+}
+
+func (v *BoundedInt) Get() interface{} {
+	return *v.v
+}
+
+func (v *BoundedInt) Set(raw string) error {
+	p, err := strconv.ParseInt(raw, 10, 0)
+	if err != nil {
+		return intError(raw, err, "int")
+	}
+	n := int(p)
+	if n < v.min || n > v.max {
+		switch {
+		case v.max == math.MaxInt:
+			return fmt.Errorf("must be at least %d", v.min)
+		case v.min == math.MinInt:
+			return fmt.Errorf("must be at most %d", v.max)
+		default:
+			return fmt.Errorf("must be between %d and %d", v.min, v.max)
+		}
+	}
+	*v.v = n
+	return nil
+}
+
+func (v *BoundedInt) Clone() flag.Value {
+	c := *v.v
+	return &BoundedInt{&c, v.min, v.max}
+}
+
+func (v *BoundedInt) TypeName() string {
+	return "int"
+}
+
+func NewInt64(v int64) *Int64 {
+	vv := Int64(v)
+	return &vv
+}
+
+func Int64Var(v *int64) *Int64 {
+	return (*Int64)(v)
+}
+
+type Int64 int64
+
+func (v Int64) String() string {
+	return strconv.FormatInt(int64(v), 10)
+}
+
+func (v Int64) Get() interface{} {
+	return int64(v)
+}
+
+func (v *Int64) Ptr() *int64 {
+	return (*int64)(v)
+}
+
+func (v *Int64) Set(raw string) error {
+	p, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return intError(raw, err, "int64")
+	}
+	*v = Int64(p)
+	return nil
+}
+
+func (v *Int64) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Int64) TypeName() string {
+	return "int64"
+}
+
+func UintVar(v *uint) *Uint {
+	return (*Uint)(v)
+}
+
+type Uint uint
+
+func (v Uint) String() string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func (v Uint) Get() interface{} {
+	return uint(v)
+}
+
+func (v *Uint) Set(raw string) error {
+	p, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil {
+		return intError(raw, err, "uint")
+	}
+	*v = Uint(p)
+	return nil
+}
+
+func (v *Uint) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Uint) TypeName() string {
+	return "uint"
+}
+
+func Uint64Var(v *uint64) *Uint64 {
+	return (*Uint64)(v)
+}
+
+type Uint64 uint64
+
+func (v Uint64) String() string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func (v Uint64) Get() interface{} {
+	return uint64(v)
+}
+
+func (v *Uint64) Set(raw string) error {
+	p, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return intError(raw, err, "uint64")
+	}
+	*v = Uint64(p)
+	return nil
+}
+
+func (v *Uint64) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Uint64) TypeName() string {
+	return "uint64"
+}
+
+// intError turns strconv's range errors into messages naming the target type,
+// which matters for int and uint, whose size differs between platforms.
+func intError(raw string, err error, typ string) error {
+	switch {
+	case errors.Is(err, strconv.ErrRange) && (typ == "int" || typ == "uint"):
+		return fmt.Errorf("value %s overflows %s on this platform (%d-bit)", raw, typ, strconv.IntSize)
+	case errors.Is(err, strconv.ErrRange):
+		return fmt.Errorf("value %s overflows %s", raw, typ)
+	case strings.HasPrefix(typ, "uint") && strings.HasPrefix(strings.TrimSpace(raw), "-"):
+		return fmt.Errorf("must not be negative")
+	}
+	return err
+}
+
+func NewSlogLevel(v slog.Level) *SlogLevel {
+	vv := SlogLevel(v)
+	return &vv
+}
+
+// SlogLevelVar returns a value that parses slog level names like debug, info,
+// warn and error, case-insensitively and optionally with an offset like warn+4,
+// following slog.Level.UnmarshalText.
+func SlogLevelVar(v *slog.Level) *SlogLevel {
+	return (*SlogLevel)(v)
 }
 
-type Duration time.Duration
+type SlogLevel slog.Level
 
-func (v Duration) String() string {
-	return time.Duration(v).String()
+func (v SlogLevel) String() string {
+	return slog.Level(v).String()
 }
 
-func (v Duration) Get() interface{} {
-	return time.Duration(v)
+func (v SlogLevel) Get() interface{} {
+	return slog.Level(v)
 }
 
-func (v *Duration) Set(raw string) error {
-	p, err := time.ParseDuration(raw)
+func (v *SlogLevel) Set(raw string) error {
+	var p slog.Level
+	err := p.UnmarshalText([]byte(raw))
 	if err != nil {
 		return err
 	}
-	*v = Duration(p)
+	*v = SlogLevel(p)
 	return nil
 }
 
-func NewInt(v int) *Int {
-	vv := Int(v)
+func (v *SlogLevel) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *SlogLevel) TypeName() string {
+	return "loglevel"
+}
+
+func NewFloat64(v float64) *Float64 {
+	vv := Float64(v)
 	return &vv
 }
 
-func IntVar(v *int) *Int {
-	return (*Int)(v)
+func Float64Var(v *float64) *Float64 {
+	return (*Float64)(v)
 }
 
-type Int int
+type Float64 float64
 
-func (v Int) String() string {
+func (v Float64) String() string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 64)
+}
+
+func (v Float64) Get() interface{} {
+	return float64(v)
+}
+
+func (v *Float64) Set(raw string) error {
+	p, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return err
+	}
+	*v = Float64(p)
+	return nil
+}
+
+func (v *Float64) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Float64) TypeName() string {
+	return "float64"
+}
+
+// PercentVar returns a value that parses a fraction, given either as a plain
+// number (0.75) or as a percentage (75%), so both of these mean the same.
+// Values outside of 0..1 are rejected unless the range is changed via Range.
+// String renders the percentage form.
+func PercentVar(v *float64) *Percent {
+	return &Percent{v, 0, 1}
+}
+
+type Percent struct {
+	v        *float64
+	min, max float64
+}
+
+// Range sets the inclusive range of accepted fractions.
+func (v *Percent) Range(min, max float64) *Percent {
+	v.min, v.max = min, max
+	return v
+}
+
+func (v *Percent) String() string {
+	if v.v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v.v*100, 'g', 12, 64) + "%"
+}
+
+func (v *Percent) Get() interface{} {
+	return *v.v
+}
+
+func (v *Percent) Set(raw string) error {
+	s, isPercent := strings.CutSuffix(raw, "%")
+	p, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return err
+	}
+	if !isFinite(p) {
+		return fmt.Errorf("must be a finite number")
+	}
+	if isPercent {
+		p /= 100
+	}
+	if p < v.min || p > v.max {
+		return fmt.Errorf("must be between %s%% and %s%%", strconv.FormatFloat(v.min*100, 'g', 12, 64), strconv.FormatFloat(v.max*100, 'g', 12, 64))
+	}
+	*v.v = p
+	return nil
+}
+
+func (v *Percent) Clone() flag.Value {
+	c := *v.v
+	return &Percent{&c, v.min, v.max}
+}
+
+func (v *Percent) TypeName() string {
+	return "percent"
+}
+
+// RolloutPercentVar returns a value for gradual rollout knobs, parsing
+// a percentage of 0..100, optionally with a % sign, or on and off meaning
+// 100 and 0. Use Enabled to decide whether a particular user or entity falls
+// into the enabled percentage.
+func RolloutPercentVar(v *int) *RolloutPercent {
+	return (*RolloutPercent)(v)
+}
+
+type RolloutPercent int
+
+func (v RolloutPercent) String() string {
 	return strconv.Itoa(int(v))
 }
 
-func (v Int) Get() interface{} {
+func (v RolloutPercent) Get() interface{} {
 	return int(v)
 }
 
-func (v *Int) Set(raw string) error {
-	p, err := strconv.ParseInt(raw, 10, 0)
+func (v *RolloutPercent) Set(raw string) error {
+	switch strings.ToLower(raw) {
+	case "on":
+		*v = 100
+		return nil
+	case "off":
+		*v = 0
+		return nil
+	}
+	p, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(raw, "%")))
 	if err != nil {
-		return err
+		return fmt.Errorf("expected a percentage between 0 and 100, or on/off")
 	}
-	*v = Int(p)
+	if p < 0 || p > 100 {
+		return fmt.Errorf("must be between 0 and 100")
+	}
+	*v = RolloutPercent(p)
 	return nil
 }
 
-func NewInt64(v int64) *Int64 {
-	vv := Int64(v)
-	return &vv
+func (v *RolloutPercent) Clone() flag.Value {
+	c := *v
+	return &c
 }
 
-func Int64Var(v *int64) *Int64 {
-	return (*Int64)(v)
+func (v *RolloutPercent) TypeName() string {
+	return "rollout"
 }
 
-type Int64 int64
+// Enabled deterministically assigns key, e.g. a user ID, to one of 100 buckets
+// by its hash, and reports whether the bucket falls within the percentage.
+// The same key always gets the same answer, and raising the percentage
+// only ever enables more keys.
+func (v RolloutPercent) Enabled(key string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32()%100) < int(v)
+}
 
-func (v Int64) String() string {
-	return strconv.FormatInt(int64(v), 10)
+// RatioVar returns a value that parses a ratio given either as a fraction
+// like 1/100 or as a plain number like 0.01. String renders ratios of the form
+// 1/N as such, and other ones as plain numbers.
+func RatioVar(v *float64) *Ratio {
+	return (*Ratio)(v)
 }
 
-func (v Int64) Get() interface{} {
-	return int64(v)
+type Ratio float64
+
+func (v Ratio) String() string {
+	if v > 0 && v < 1 {
+		if n := math.Round(1 / float64(v)); math.Abs(1/n-float64(v)) < 1e-12 {
+			return "1/" + strconv.FormatFloat(n, 'f', -1, 64)
+		}
+	}
+	return strconv.FormatFloat(float64(v), 'g', -1, 64)
 }
 
-func (v *Int64) Ptr() *int64 {
-	return (*int64)(v)
+func (v Ratio) Get() interface{} {
+	return float64(v)
 }
 
-func (v *Int64) Set(raw string) error {
-	p, err := strconv.ParseInt(raw, 10, 64)
+func (v *Ratio) Set(raw string) error {
+	numStr, denomStr, isFraction := strings.Cut(raw, "/")
+	num, err := strconv.ParseFloat(strings.TrimSpace(numStr), 64)
 	if err != nil {
 		return err
 	}
-	*v = Int64(p)
+	if isFraction {
+		denom, err := strconv.ParseFloat(strings.TrimSpace(denomStr), 64)
+		if err != nil {
+			return err
+		}
+		if denom == 0 {
+			return fmt.Errorf("zero denominator")
+		}
+		if !isFinite(denom) {
+			return fmt.Errorf("must be a finite number")
+		}
+		num /= denom
+	}
+	if !isFinite(num) {
+		return fmt.Errorf("must be a finite number")
+	}
+	*v = Ratio(num)
 	return nil
 }
 
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func (v *Ratio) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Ratio) TypeName() string {
+	return "ratio"
+}
+
 func NewBool(v bool) *Bool {
 	vv := Bool(v)
 	return &vv
@@ -152,6 +1205,187 @@ func (v *Bool) Set(raw string) error {
 	return nil
 }
 
+func (v *Bool) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *Bool) TypeName() string {
+	return "bool"
+}
+
+// FlagBoolVar returns a boolean that, unlike BoolVar, treats a present but empty
+// value as true, following the shell idiom of enabling a feature by defining
+// the variable with VERBOSE=. Other values are parsed like BoolVar.
+//
+// Telling empty values from absent ones requires a lookup in the style of
+// os.LookupEnv, as used by TryParse, TryParseMap and TryParseEnviron.
+// With a getenv func, as in TryParseFrom, empty values are absent and keep the default.
+func FlagBoolVar(v *bool) *FlagBool {
+	return (*FlagBool)(v)
+}
+
+type FlagBool bool
+
+func (v FlagBool) String() string {
+	return strconv.FormatBool(bool(v))
+}
+
+func (v FlagBool) Get() interface{} {
+	return bool(v)
+}
+
+func (v *FlagBool) Set(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		*v = true
+		return nil
+	}
+	p, err := parseBool(raw)
+	if err != nil {
+		return err
+	}
+	*v = FlagBool(p)
+	return nil
+}
+
+func (v *FlagBool) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *FlagBool) TypeName() string {
+	return "flagbool"
+}
+
+// BoolVarWith returns a value that parses booleans using the given literals
+// instead of the default ones, e.g. []string{"yes", "y"} and []string{"no", "n"}.
+// Matching is exact. String renders the first literal of the respective list.
+func BoolVarWith(v *bool, truthy, falsy []string) *BoolWith {
+	return &BoolWith{v, truthy, falsy}
+}
+
+type BoolWith struct {
+	v      *bool
+	truthy []string
+	falsy  []string
+}
+
+func (v *BoolWith) String() string {
+	if v.v == nil {
+		return ""
+	}
+	lits := v.falsy
+	if *v.v {
+		lits = v.truthy
+	}
+	if len(lits) == 0 {
+		return strconv.FormatBool(*v.v)
+	}
+	return lits[0]
+}
+
+func (v *BoolWith) Get() interface{} {
+	return *v.v
+}
+
+func (v *BoolWith) Set(raw string) error {
+	for _, lit := range v.truthy {
+		if raw == lit {
+			*v.v = true
+			return nil
+		}
+	}
+	for _, lit := range v.falsy {
+		if raw == lit {
+			*v.v = false
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid boolean value, expected one of %s", strings.Join(append(append([]string(nil), v.truthy...), v.falsy...), ", "))
+}
+
+func (v *BoolWith) Clone() flag.Value {
+	c := *v.v
+	return &BoolWith{&c, v.truthy, v.falsy}
+}
+
+func (v *BoolWith) TypeName() string {
+	return "boolwith"
+}
+
+// OnOffBoolVar returns a value that parses booleans like BoolVar,
+// but renders them as on or off.
+func OnOffBoolVar(v *bool) *OnOffBool {
+	return (*OnOffBool)(v)
+}
+
+type OnOffBool bool
+
+func (v OnOffBool) String() string {
+	if v {
+		return "on"
+	}
+	return "off"
+}
+
+func (v OnOffBool) Get() interface{} {
+	return bool(v)
+}
+
+func (v *OnOffBool) Set(raw string) error {
+	p, err := parseBool(raw)
+	if err != nil {
+		return err
+	}
+	*v = OnOffBool(p)
+	return nil
+}
+
+func (v *OnOffBool) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *OnOffBool) TypeName() string {
+	return "bool"
+}
+
+// InvertedBoolVar returns a value for opt-out keys like NO_CACHE that stores
+// the negation of the parsed boolean into v: NO_CACHE=1 sets v to false.
+// String renders the key's point of view, i.e. "true" when v is false,
+// while Get returns v itself.
+func InvertedBoolVar(v *bool) *InvertedBool {
+	return (*InvertedBool)(v)
+}
+
+type InvertedBool bool
+
+func (v InvertedBool) String() string {
+	return strconv.FormatBool(!bool(v))
+}
+
+func (v InvertedBool) Get() interface{} {
+	return bool(v)
+}
+
+func (v *InvertedBool) Set(raw string) error {
+	p, err := parseBool(raw)
+	if err != nil {
+		return err
+	}
+	*v = InvertedBool(!p)
+	return nil
+}
+
+func (v *InvertedBool) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *InvertedBool) TypeName() string {
+	return "bool"
+}
+
 func parseBool(str string) (bool, error) {
 	switch str {
 	case "1", "t", "T", "true", "TRUE", "True", "on", "On", "ON":
@@ -161,3 +1395,105 @@ func parseBool(str string) (bool, error) {
 	}
 	return false, fmt.Errorf("invalid boolean value")
 }
+
+// NullBoolVar returns a value for tristate flags: v stays nil while
+// the variable is unset, and points to true or false once specified.
+// An empty value resets v to nil.
+func NullBoolVar(v **bool) *NullBool {
+	return &NullBool{v}
+}
+
+type NullBool struct {
+	v **bool
+}
+
+func (v *NullBool) String() string {
+	if v.v == nil || *v.v == nil {
+		return ""
+	}
+	return strconv.FormatBool(**v.v)
+}
+
+func (v *NullBool) Get() interface{} {
+	return *v.v
+}
+
+func (v *NullBool) Set(raw string) error {
+	if raw == "" {
+		*v.v = nil
+		return nil
+	}
+	p, err := parseBool(raw)
+	if err != nil {
+		return err
+	}
+	*v.v = &p
+	return nil
+}
+
+func (v *NullBool) Clone() flag.Value {
+	var c *bool
+	if *v.v != nil {
+		b := **v.v
+		c = &b
+	}
+	return &NullBool{&c}
+}
+
+func (v *NullBool) TypeName() string {
+	return "nullbool"
+}
+
+// Toggle is an enabled flag with an optional time limit, as returned by BoolOrDuration.Get.
+type Toggle struct {
+	Enabled  bool
+	Duration time.Duration // zero when enabled without a limit
+}
+
+// BoolOrDurationVar returns a value for feature toggles that accepts booleans
+// like on and off, or a positive duration like 30m meaning enabled for that long.
+func BoolOrDurationVar(v *Toggle) *BoolOrDuration {
+	return (*BoolOrDuration)(v)
+}
+
+type BoolOrDuration Toggle
+
+func (v BoolOrDuration) String() string {
+	switch {
+	case v.Duration > 0:
+		return v.Duration.String()
+	case v.Enabled:
+		return "on"
+	default:
+		return "off"
+	}
+}
+
+func (v BoolOrDuration) Get() interface{} {
+	return Toggle(v)
+}
+
+func (v *BoolOrDuration) Set(raw string) error {
+	if b, err := parseBool(raw); err == nil {
+		*v = BoolOrDuration{Enabled: b}
+		return nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("expected on, off or a duration like 30m")
+	}
+	if d <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	*v = BoolOrDuration{Enabled: true, Duration: d}
+	return nil
+}
+
+func (v *BoolOrDuration) Clone() flag.Value {
+	c := *v
+	return &c
+}
+
+func (v *BoolOrDuration) TypeName() string {
+	return "bool|duration"
+}
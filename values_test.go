@@ -0,0 +1,32 @@
+package envloader
+
+import "testing"
+
+func TestPercentRejectsNonFinite(t *testing.T) {
+	var f float64
+	var n int
+	for _, raw := range []string{"NaN", "nan%", "Inf", "-Inf%", "+Inf"} {
+		if err := PercentVar(&f).Set(raw); err == nil {
+			t.Errorf("Percent.Set(%q) = nil, value %v", raw, f)
+		}
+		if err := PercentVar(&f).Range(-1e308, 1e308).Set(raw); err == nil {
+			t.Errorf("Percent.Set(%q) with a wide range = nil, value %v", raw, f)
+		}
+		if err := RolloutPercentVar(&n).Set(raw); err == nil {
+			t.Errorf("RolloutPercent.Set(%q) = nil, value %v", raw, n)
+		}
+	}
+}
+
+func TestRatioRejectsNonFinite(t *testing.T) {
+	for _, raw := range []string{"NaN", "Inf", "-Inf", "1/Inf", "Inf/2", "NaN/1", "1e308/1e-308"} {
+		var r Ratio
+		if err := r.Set(raw); err == nil {
+			t.Errorf("Ratio.Set(%q) = nil, value %v", raw, float64(r))
+		}
+	}
+	var r Ratio
+	if err := r.Set("1/4"); err != nil || r != 0.25 {
+		t.Errorf("Ratio.Set(1/4) = %v, value %v", err, float64(r))
+	}
+}